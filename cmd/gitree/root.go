@@ -2,8 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/andreygrechin/gitree/internal/cli"
@@ -22,6 +28,15 @@ const (
 	spinnerDelay          = 100 * time.Millisecond
 	spinnerChar           = 11
 	defaultContextTimeout = 5 * time.Minute
+
+	// Values of the --cache flag.
+	cacheOn  = "on"
+	cacheOff = "off"
+)
+
+var (
+	errInvalidFlag = errors.New("invalid flag value")
+	errRootsFailed = errors.New("not every root could be scanned")
 )
 
 //nolint:gochecknoglobals // CLI flags and root command
@@ -31,19 +46,56 @@ var (
 	noColorFlag bool
 	allFlag     bool
 	debugFlag   bool
+	nestedFlag  bool
+
+	// Scan scope flags.
+	maxDepthFlag          int
+	excludeFlag           []string
+	includeFlag           []string
+	skipHiddenFlag        bool
+	noDefaultExcludesFlag bool
+	oneFileSystemFlag     bool
+	skipSpecialFSFlag     bool
+	followSymlinksFlag    string
+	scanConcurrencyFlag   int
+
+	// Scan cache flags.
+	cacheFlag  string
+	rescanFlag bool
+
+	// Status flags.
+	defaultBranchesFlag []string
+	branchesFlag        bool
+	backendFlag         string
+	timeoutFlag         time.Duration
+	retriesFlag         int
+
+	// Display flags.
+	changesFlag        string
+	showLastCommitFlag bool
+	sortFlag           string
+	submodulesFlag     bool
+
+	// Filter flags.
+	stashOlderThanFlag string
+	staleFlag          string
+	staleFilterFlag    string
 
 	// Root command.
 	rootCmd = &cobra.Command{
-		Use:   "gitree",
+		Use:   "gitree [path...]",
 		Short: "Recursively scan directories for Git repositories and display them in a tree structure",
-		Long: `gitree scans the current directory and its subdirectories for Git repositories,
-displays them in a tree structure with status information.
+		Long: `gitree scans the given directories (or the current directory if none are given)
+and their subdirectories for Git repositories, and displays them in a tree structure
+with status information. Each directory is shown as a separate tree; if any of them
+cannot be scanned, the others are still shown and gitree exits with status 1.
 
 By default, only repositories needing attention are shown (uncommitted changes,
-non-main/master branches, ahead/behind remote, stashes, or no remote tracking).
+branches other than the default one, ahead/behind remote, stashes, or no remote tracking).
 Use --all to show all repositories including clean ones.`,
 		SilenceErrors: true,
 		SilenceUsage:  true,
+		Args:          cobra.ArbitraryArgs,
 		RunE:          runGitree,
 	}
 
@@ -62,6 +114,53 @@ func init() { //nolint:gochecknoinits // Cobra CLI initialization
 	rootCmd.Flags().BoolVarP(&allFlag, "all", "a", false,
 		"Show all repositories including clean ones (default shows only repos needing attention)")
 	rootCmd.Flags().BoolVar(&debugFlag, "debug", false, "Enable debug output")
+	rootCmd.Flags().BoolVar(&nestedFlag, "nested", false,
+		"Also find repositories nested inside other repositories (paths the enclosing repository ignores are only searched one level deep)")
+	rootCmd.Flags().IntVar(&maxDepthFlag, "max-depth", 0, "Maximum directory depth to descend into (0 = unlimited)")
+	rootCmd.Flags().StringSliceVar(&excludeFlag, "exclude", nil,
+		"Glob patterns of directories to skip (matched against the name, or the relative path if it contains '/')")
+	rootCmd.Flags().StringSliceVar(&includeFlag, "include", nil,
+		"Glob patterns of directories to scan even if excluded, hidden or in the default exclude set")
+	rootCmd.Flags().BoolVar(&skipHiddenFlag, "skip-hidden", false, "Skip hidden directories (names starting with '.')")
+	rootCmd.Flags().BoolVar(&noDefaultExcludesFlag, "no-default-excludes", false,
+		"Do not skip the built-in exclude set ("+strings.Join(scanner.DefaultExcludes, ", ")+", ~/.cache, and ~/Library on macOS)")
+	rootCmd.Flags().BoolVar(&oneFileSystemFlag, "one-file-system", false,
+		"Do not descend into directories on other file systems than the scanned directory")
+	rootCmd.Flags().BoolVar(&skipSpecialFSFlag, "skip-special-fs", false,
+		"Do not descend into pseudo (proc, sysfs, ...) or network (NFS, SMB, FUSE, ...) mounts")
+	rootCmd.Flags().StringVar(&followSymlinksFlag, "follow-symlinks", string(scanner.FollowSymlinksNever),
+		"Walk into symlinked directories: never, within-root (target inside the scanned directory) or always")
+	rootCmd.Flags().IntVar(&scanConcurrencyFlag, "scan-concurrency", scanner.DefaultConcurrency,
+		"Number of directories read in parallel while scanning (1 = sequential)")
+	rootCmd.Flags().StringVar(&cacheFlag, "cache", cacheOn,
+		"Remember directory listings between runs and only re-read changed directories: on or off")
+	rootCmd.Flags().BoolVar(&rescanFlag, "rescan", false, "Ignore the scan cache and walk every directory (the cache is still updated)")
+	rootCmd.Flags().StringSliceVar(&defaultBranchesFlag, "default-branches", gitstatus.DefaultBranchNames(),
+		"Branch names tried in order as the default branch when a repository's remote HEAD and init.defaultBranch do not tell")
+	rootCmd.Flags().BoolVar(&branchesFlag, "branches", false,
+		"Audit every local branch: list the other branches under each repository with their ahead/behind counts, "+
+			"missing (◇) or deleted (⊘) upstream, and whether they are merged into the default branch")
+	rootCmd.Flags().StringVar(&backendFlag, "backend", string(gitstatus.BackendGoGit),
+		"How to read working tree status: go-git (built in), git (run git status, faster on large trees) or auto (git if installed)")
+	rootCmd.Flags().DurationVar(&timeoutFlag, "timeout", defaultTimeout,
+		"Maximum time to read the status of each repository (e.g. 30s, 2m; 0 = no limit)")
+	rootCmd.Flags().IntVar(&retriesFlag, "retries", 0,
+		"Number of times to try again to read the status of a repository that failed or timed out")
+	rootCmd.Flags().StringVar(&changesFlag, "changes", string(models.ChangesSummary),
+		"How to show uncommitted changes: summary (*) or detailed (+staged ~modified -deleted »renamed ?untracked !unmerged)")
+	rootCmd.Flags().BoolVar(&showLastCommitFlag, "show-last-commit", false,
+		"Show the last commit of each repository (hash, date, author, subject)")
+	rootCmd.Flags().StringVar(&sortFlag, "sort", string(tree.SortByName),
+		"Order of repositories in each directory: name or activity (most recent commit first)")
+	rootCmd.Flags().BoolVar(&submodulesFlag, "submodules", false,
+		"List the submodules of each repository under it, marking uninitialized, out of sync (another commit than recorded) "+
+			"and modified ones")
+	rootCmd.Flags().StringVar(&stashOlderThanFlag, "stash-older-than", "",
+		"Only show repositories with a stash older than this age (e.g. 30d, 2w, 36h)")
+	rootCmd.Flags().StringVar(&staleFlag, "stale", "",
+		"Flag repositories whose HEAD and local branches have not changed for longer than this age as stale (e.g. 90d)")
+	rootCmd.Flags().StringVar(&staleFilterFlag, "stale-filter", string(cli.StaleFlag),
+		"What to do with stale repositories: flag (show them with ⧗), only (show nothing else) or hide")
 
 	// Set PersistentPreRun to handle global flags (color suppression)
 	rootCmd.PersistentPreRun = handleGlobalFlags
@@ -101,11 +200,176 @@ func formatVersion(ver, cmt, btime string) string {
 	return fmt.Sprintf("gitree version %s\n  commit: %s\n  built:  %s", ver, cmt, btime)
 }
 
-func runGitree(_ *cobra.Command, _ []string) error {
-	// Get current working directory
+// scanRoot pairs a root directory given on the command line with its scan result.
+type scanRoot struct {
+	label  string             // Root as given on the command line ("." for the current directory)
+	result *models.ScanResult // Result of scanning the root
+}
+
+// resolveRoots returns the root directories to scan: the positional arguments
+// if any were given, otherwise the current working directory labeled ".".
+func resolveRoots(args []string) (paths, labels []string, err error) {
+	if len(args) > 0 {
+		return args, args, nil
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("unable to get current directory: %w", err)
+		return nil, nil, fmt.Errorf("unable to get current directory: %w", err)
+	}
+
+	return []string{cwd}, []string{"."}, nil
+}
+
+// scanOptions returns the scan options selected by the command line flags, without a root path.
+func scanOptions() (scanner.ScanOptions, error) {
+	followSymlinks, err := scanner.ParseFollowSymlinks(followSymlinksFlag)
+	if err != nil {
+		return scanner.ScanOptions{}, fmt.Errorf("invalid --follow-symlinks value: %w", err)
+	}
+
+	opts := scanner.ScanOptions{
+		Debug:             debugFlag,
+		Nested:            nestedFlag,
+		MaxDepth:          maxDepthFlag,
+		Exclude:           excludeFlag,
+		Include:           includeFlag,
+		SkipHidden:        skipHiddenFlag,
+		NoDefaultExcludes: noDefaultExcludesFlag,
+		OneFileSystem:     oneFileSystemFlag,
+		SkipSpecialFS:     skipSpecialFSFlag,
+		FollowSymlinks:    followSymlinks,
+		Concurrency:       scanConcurrencyFlag,
+		Rescan:            rescanFlag,
+	}
+
+	switch cacheFlag {
+	case cacheOn:
+		cacheDir, err := scanner.DefaultCacheDir()
+		if err != nil {
+			// Scanning works without the cache, just slower
+			fmt.Fprintf(os.Stderr, "Warning: scan cache disabled: %v\n", err)
+		}
+		opts.CacheDir = cacheDir
+	case cacheOff:
+	default:
+		return scanner.ScanOptions{}, fmt.Errorf("invalid --cache value %q (must be %s or %s): %w",
+			cacheFlag, cacheOn, cacheOff, errInvalidFlag)
+	}
+
+	return opts, nil
+}
+
+// scanRoots scans every root concurrently with the given options. A root that cannot
+// be scanned produces an error for that root only; the remaining roots are still scanned.
+// If found is set, it is called with each repository as soon as it is discovered
+// (from several goroutines at once), so processing can start before the scan ends.
+func scanRoots(
+	ctx context.Context, paths, labels []string, opts scanner.ScanOptions, found func(*models.Repository),
+) ([]*scanRoot, []error) {
+	results := make([]*scanRoot, len(paths))
+	errs := make([]error, len(paths))
+
+	var wg sync.WaitGroup
+
+	for i, path := range paths {
+		scanOpts := opts
+		scanOpts.RootPath = path
+		stream, err := scanner.ScanStream(ctx, scanOpts)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to scan %s: %w", labels[i], err)
+
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for repo := range stream.Repositories {
+				if found != nil {
+					found(repo)
+				}
+			}
+
+			scanResult, err := stream.Result()
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to scan %s: %w", labels[i], err)
+
+				return
+			}
+			results[i] = &scanRoot{label: labels[i], result: scanResult}
+		}()
+	}
+
+	wg.Wait()
+
+	// Keep the command line order of roots and errors
+	roots := make([]*scanRoot, 0, len(paths))
+	for _, root := range results {
+		if root != nil {
+			roots = append(roots, root)
+		}
+	}
+
+	return roots, slices.DeleteFunc(errs, func(err error) bool { return err == nil })
+}
+
+func runGitree(_ *cobra.Command, args []string) error {
+	// Resolve root directories (positional arguments or current directory)
+	paths, labels, err := resolveRoots(args)
+	if err != nil {
+		return err
+	}
+
+	scanOpts, err := scanOptions()
+	if err != nil {
+		return err
+	}
+
+	changesMode, err := models.ParseChangesMode(changesFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --changes value: %w", err)
+	}
+
+	backend, err := gitstatus.ParseBackend(backendFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --backend value: %w", err)
+	}
+
+	if timeoutFlag < 0 {
+		return fmt.Errorf("invalid --timeout value %s (must not be negative): %w", timeoutFlag, errInvalidFlag)
+	}
+	if retriesFlag < 0 {
+		return fmt.Errorf("invalid --retries value %d (must not be negative): %w", retriesFlag, errInvalidFlag)
+	}
+
+	sortOrder, err := tree.ParseSortOrder(sortFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --sort value: %w", err)
+	}
+
+	filterOpts := cli.FilterOptions{ShowAll: allFlag}
+	if stashOlderThanFlag != "" {
+		filterOpts.StashOlderThan, err = cli.ParseAge(stashOlderThanFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --stash-older-than value: %w", err)
+		}
+	}
+
+	var staleAfter time.Duration
+	if staleFlag != "" {
+		staleAfter, err = cli.ParseAge(staleFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --stale value: %w", err)
+		}
+	}
+	filterOpts.Stale, err = cli.ParseStaleFilter(staleFilterFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --stale-filter value: %w", err)
+	}
+	if filterOpts.Stale != cli.StaleFlag && staleAfter == 0 {
+		return fmt.Errorf("--stale-filter %s requires --stale: %w", filterOpts.Stale, errInvalidFlag)
 	}
 
 	// Initialize spinner
@@ -121,87 +385,208 @@ func runGitree(_ *cobra.Command, _ []string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), defaultContextTimeout)
 	defer cancel()
 
-	// Scan for repositories
-	scanOpts := scanner.ScanOptions{
-		RootPath: cwd,
-		Debug:    debugFlag,
-	}
-	scanResult, err := scanner.Scan(ctx, scanOpts)
-	if err != nil {
-		if !debugFlag {
-			s.Stop()
+	// Extract Git status concurrently, starting on each repository as soon as it is discovered
+	var discovered, processed atomic.Int64
+	updateProgress := func() {
+		if debugFlag {
+			return
 		}
-
-		return fmt.Errorf("failed to scan directory: %w", err)
+		s.Lock()
+		s.Suffix = fmt.Sprintf(" Scanning repositories... %d found, %d processed", discovered.Load(), processed.Load())
+		s.Unlock()
+	}
+	statusOpts := &gitstatus.ExtractOptions{
+		Timeout:         timeoutFlag,
+		Retries:         retriesFlag,
+		MaxConcurrency:  maxConcurrentRequests,
+		Debug:           debugFlag,
+		StaleAfter:      staleAfter,
+		DefaultBranches: defaultBranchesFlag,
+		AllBranches:     branchesFlag,
+		Backend:         backend,
+		Progress: func(string) {
+			processed.Add(1)
+			updateProgress()
+		},
 	}
 
-	// Check if any repositories were found
-	if len(scanResult.Repositories) == 0 {
+	// Scan each root for repositories, feeding them to status extraction
+	var (
+		roots    []*scanRoot
+		scanErrs []error
+	)
+	repoQueue := make(chan *models.Repository)
+	go func() {
+		defer close(repoQueue)
+
+		roots, scanErrs = scanRoots(ctx, paths, labels, scanOpts, func(repo *models.Repository) {
+			discovered.Add(1)
+			updateProgress()
+			select {
+			case repoQueue <- repo:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	// Returns once the scan has finished and every repository has been processed.
+	// Repositories that failed still have a partial status; their errors are reported
+	// on the repositories and summarized at the end.
+	statuses, extractErr := gitstatus.ExtractStream(ctx, repoQueue, statusOpts)
+	repoErrs := gitstatus.RepositoryErrors(extractErr)
+
+	if len(roots) == 0 {
 		if !debugFlag {
 			s.Stop()
 		}
-		_, _ = fmt.Fprintln(os.Stdout, "No Git repositories found in this directory.")
 
-		return nil
-	}
+		if len(scanErrs) == 1 {
+			return scanErrs[0]
+		}
 
-	// Update spinner message
-	s.Suffix = fmt.Sprintf(" Extracting Git status for %d repositories...", len(scanResult.Repositories))
+		return errors.Join(scanErrs...)
+	}
 
-	// Create map of repositories for batch processing
-	repoMap := make(map[string]*models.Repository)
-	for _, repo := range scanResult.Repositories {
-		repoMap[repo.Path] = repo
+	// The repositories of the other roots are shown, but the command still fails
+	var rootsErr error
+	if len(scanErrs) > 0 {
+		rootsErr = fmt.Errorf("%w: %d of %d failed", errRootsFailed, len(scanErrs), len(paths))
 	}
 
-	// Extract Git status concurrently
-	statusOpts := &gitstatus.ExtractOptions{
-		Timeout:        defaultTimeout,
-		MaxConcurrency: maxConcurrentRequests,
-		Debug:          debugFlag,
+	// Collect repositories from all roots
+	var allRepos []*models.Repository
+	for _, root := range roots {
+		allRepos = append(allRepos, root.result.Repositories...)
 	}
-	statuses, err := gitstatus.ExtractBatch(ctx, repoMap, statusOpts)
-	if err != nil {
+
+	// Check if any repositories were found
+	if len(allRepos) == 0 {
 		if !debugFlag {
 			s.Stop()
 		}
-		fmt.Fprintf(os.Stderr, "Warning: Some repositories failed status extraction: %v\n", err)
-		// Continue anyway with partial results
+		printScanSummary(roots, scanErrs)
+		if len(paths) == 1 {
+			_, _ = fmt.Fprintln(os.Stdout, "No Git repositories found in this directory.")
+		} else {
+			_, _ = fmt.Fprintln(os.Stdout, "No Git repositories found in these directories.")
+		}
+
+		return rootsErr
 	}
 
-	// Populate repositories with status
-	for path, status := range statuses {
-		if repo, exists := repoMap[path]; exists {
+	// Populate repositories with status and errors (overlapping roots may share a path)
+	for _, repo := range allRepos {
+		if status, exists := statuses[repo.Path]; exists {
 			repo.GitStatus = status
 		}
+		if repoErr, failed := repoErrs[repo.Path]; failed {
+			repo.Error = repoErr.Err
+			repo.HasTimeout = repoErr.IsTimeout()
+		}
+	}
+	defer printErrorSummary(os.Stderr, roots)
+
+	// Filter repositories based on --all, --stash-older-than and --stale-filter flags
+	filtered := make([][]*models.Repository, len(roots))
+	totalShown := 0
+	for i, root := range roots {
+		filtered[i] = cli.FilterRepositories(root.result.Repositories, filterOpts)
+		totalShown += len(filtered[i])
 	}
-
-	// Filter repositories based on --all flag
-	filterOpts := cli.FilterOptions{ShowAll: allFlag}
-	filteredRepos := cli.FilterRepositories(scanResult.Repositories, filterOpts)
 
 	// Check if all repos were filtered out (all clean in default mode)
-	if len(filteredRepos) == 0 && !allFlag {
+	if totalShown == 0 && (!allFlag || filterOpts.StashOlderThan > 0 || filterOpts.Stale == cli.StaleOnly) {
 		if !debugFlag {
 			s.Stop()
 		}
-		_, _ = fmt.Fprintln(os.Stdout, "All repositories are in clean state (on main/master, in sync with remote, no changes).")
+		printScanSummary(roots, scanErrs)
+		if filterOpts.StashOlderThan > 0 {
+			_, _ = fmt.Fprintf(os.Stdout, "No repositories have stashes older than %s.\n", stashOlderThanFlag)
+
+			return rootsErr
+		}
+		if filterOpts.Stale == cli.StaleOnly {
+			_, _ = fmt.Fprintf(os.Stdout, "No repositories have been inactive for longer than %s.\n", staleFlag)
+
+			return rootsErr
+		}
+		_, _ = fmt.Fprintln(os.Stdout, "All repositories are in clean state (on their default branch, in sync with remote, no changes).")
 		_, _ = fmt.Fprintln(os.Stdout, "Use --all flag to show all repositories including clean ones.")
 
-		return nil
+		return rootsErr
 	}
 
-	// Build tree structure with filtered repositories
-	root := tree.Build(cwd, filteredRepos, nil)
-
 	// Stop spinner before output
 	if !debugFlag {
 		s.Stop()
 	}
+	printScanSummary(roots, scanErrs)
 
-	// Format and print tree
-	output := tree.Format(root, nil)
-	_, _ = fmt.Fprint(os.Stdout, output)
+	// Build, format and print one tree per root with filtered repositories
+	for i, root := range roots {
+		if i > 0 {
+			_, _ = fmt.Fprintln(os.Stdout)
+		}
+		formatOpts := &tree.FormatOptions{
+			ShowRoot:       true,
+			RootLabel:      root.label,
+			Status:         models.StatusFormat{Changes: changesMode},
+			ShowLastCommit: showLastCommitFlag,
+			SortBy:         sortOrder,
+			ShowSubmodules: submodulesFlag,
+		}
+		rootNode := tree.Build(root.result.RootPath, filtered[i], formatOpts)
+		output := tree.Format(rootNode, formatOpts)
+		_, _ = fmt.Fprint(os.Stdout, output)
+	}
 
-	return nil
+	return rootsErr
+}
+
+// printErrorSummary lists the repositories whose status could not be read, with
+// the reason, and the share of repositories of each root that succeeded.
+func printErrorSummary(w io.Writer, roots []*scanRoot) {
+	for _, root := range roots {
+		var failed []*models.Repository
+		for _, repo := range root.result.Repositories {
+			if repo.Error != nil || repo.HasTimeout {
+				failed = append(failed, repo)
+			}
+		}
+		if len(failed) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(w, "\n%s: status of %d of %d repositories could not be read (%.0f%% succeeded):\n",
+			root.label, len(failed), root.result.TotalRepos, 100*root.result.SuccessRate())
+		for _, repo := range failed {
+			reason := "unknown error"
+			switch {
+			case repo.HasTimeout:
+				reason = "timed out"
+				var stepErr *gitstatus.StepError
+				if errors.As(repo.Error, &stepErr) {
+					reason += " during the " + string(stepErr.Step) + " step"
+				}
+			case repo.Error != nil:
+				reason = repo.Error.Error()
+			}
+			fmt.Fprintf(w, "  %s: %s\n", repo.Path, reason)
+		}
+	}
+}
+
+// printScanSummary reports roots that could not be scanned and mount points that were not descended into.
+func printScanSummary(roots []*scanRoot, errs []error) {
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+
+	var mounts []string
+	for _, root := range roots {
+		mounts = append(mounts, root.result.SkippedMounts...)
+	}
+	if len(mounts) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d mount point(s): %s\n", len(mounts), strings.Join(mounts, ", "))
+	}
 }
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreygrechin/gitree/internal/gitstatus"
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/andreygrechin/gitree/internal/scanner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestResolveRoots_DefaultsToCurrentDirectory verifies that without arguments the current directory is scanned.
+func TestResolveRoots_DefaultsToCurrentDirectory(t *testing.T) {
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	paths, labels, err := resolveRoots(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{cwd}, paths)
+	assert.Equal(t, []string{"."}, labels, "Current directory should be labeled '.'")
+}
+
+// TestResolveRoots_UsesPositionalArguments verifies that positional arguments are used as roots and labels.
+func TestResolveRoots_UsesPositionalArguments(t *testing.T) {
+	args := []string{"/src", "work"}
+
+	paths, labels, err := resolveRoots(args)
+
+	require.NoError(t, err)
+	assert.Equal(t, args, paths)
+	assert.Equal(t, args, labels)
+}
+
+// TestScanRoots_PerRootErrors verifies that an invalid root does not abort scanning of the others.
+func TestScanRoots_PerRootErrors(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(first, "repo", ".git"), 0o750))
+	require.NoError(t, os.MkdirAll(filepath.Join(second, "other", ".git"), 0o750))
+	missing := filepath.Join(first, "does-not-exist")
+
+	paths := []string{first, missing, second}
+	roots, errs := scanRoots(context.Background(), paths, paths, scanner.ScanOptions{}, nil)
+
+	require.Len(t, errs, 1, "Only the missing root should fail")
+	assert.Contains(t, errs[0].Error(), missing)
+	require.Len(t, roots, 2)
+	assert.Equal(t, first, roots[0].label)
+	assert.Len(t, roots[0].result.Repositories, 1)
+	assert.Equal(t, second, roots[1].label)
+	assert.Len(t, roots[1].result.Repositories, 1)
+}
+
+// TestRunGitree_FailsWhenARootFails verifies that scripts can detect a root that could not be scanned.
+func TestRunGitree_FailsWhenARootFails(t *testing.T) {
+	resetRootCommand()
+	defer resetRootCommand()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	valid := t.TempDir()
+	missing := filepath.Join(valid, "does-not-exist")
+	rootCmd.SetArgs([]string{"--all", valid, missing})
+
+	err := rootCmd.Execute()
+
+	require.ErrorIs(t, err, errRootsFailed)
+	assert.Contains(t, err.Error(), "1 of 2 failed")
+
+	rootCmd.SetArgs([]string{"--all", valid})
+	require.NoError(t, rootCmd.Execute())
+}
+
+var errCorruptRepository = errors.New("failed to open repository: corrupt")
+
+// TestPrintErrorSummary verifies that failed repositories are listed with their reason and the success rate.
+func TestPrintErrorSummary(t *testing.T) {
+	repos := []*models.Repository{
+		{Path: "/src/ok", Name: "ok"},
+		{Path: "/src/slow", Name: "slow", HasTimeout: true, Error: context.DeadlineExceeded},
+		{Path: "/src/broken", Name: "broken", Error: errCorruptRepository},
+		{Path: "/src/fine", Name: "fine"},
+		{
+			Path: "/src/huge", Name: "huge", HasTimeout: true,
+			Error: &gitstatus.StepError{Step: gitstatus.StepWorktree, Err: context.DeadlineExceeded},
+		},
+	}
+	roots := []*scanRoot{
+		{label: "src", result: &models.ScanResult{RootPath: "/src", Repositories: repos, TotalRepos: len(repos)}},
+		{label: "clean", result: &models.ScanResult{RootPath: "/clean", Repositories: repos[:1], TotalRepos: 1}},
+	}
+
+	var buf bytes.Buffer
+	printErrorSummary(&buf, roots)
+
+	assert.Equal(t, `
+src: status of 3 of 5 repositories could not be read (40% succeeded):
+  /src/slow: timed out
+  /src/broken: failed to open repository: corrupt
+  /src/huge: timed out during the worktree step
+`, buf.String())
+}
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/andreygrechin/gitree/internal/gitstatus"
+	"github.com/andreygrechin/gitree/internal/scanner"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -135,6 +137,30 @@ func resetRootCommand() {
 	versionFlag = false
 	noColorFlag = false
 	allFlag = false
+	nestedFlag = false
+	maxDepthFlag = 0
+	excludeFlag = nil
+	includeFlag = nil
+	skipHiddenFlag = false
+	noDefaultExcludesFlag = false
+	oneFileSystemFlag = false
+	skipSpecialFSFlag = false
+	followSymlinksFlag = "never"
+	scanConcurrencyFlag = scanner.DefaultConcurrency
+	cacheFlag = "on"
+	rescanFlag = false
+	defaultBranchesFlag = gitstatus.DefaultBranchNames()
+	branchesFlag = false
+	backendFlag = "go-git"
+	timeoutFlag = 10 * time.Second
+	retriesFlag = 0
+	changesFlag = "summary"
+	showLastCommitFlag = false
+	sortFlag = "name"
+	submodulesFlag = false
+	stashOlderThanFlag = ""
+	staleFlag = ""
+	staleFilterFlag = "flag"
 
 	// Reset command args
 	rootCmd.SetArgs([]string{})
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	day  = 24 * time.Hour
+	week = 7 * day
+)
+
+var errInvalidAge = errors.New("invalid age")
+
+// ParseAge parses a positive age given on the command line: a whole number of days
+// or weeks ("90d", "2w"), or a Go duration ("36h", "1h30m").
+func ParseAge(value string) (time.Duration, error) {
+	var unit time.Duration
+	switch {
+	case strings.HasSuffix(value, "d"):
+		unit = day
+	case strings.HasSuffix(value, "w"):
+		unit = week
+	}
+
+	var age time.Duration
+	if unit > 0 {
+		n, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q (use e.g. 90d, 2w or 36h)", errInvalidAge, value)
+		}
+		age = time.Duration(n) * unit
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %q (use e.g. 90d, 2w or 36h)", errInvalidAge, value)
+		}
+		age = d
+	}
+
+	if age <= 0 {
+		return 0, fmt.Errorf("%w: %q must be positive", errInvalidAge, value)
+	}
+
+	return age, nil
+}
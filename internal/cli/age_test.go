@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"90d", 90 * 24 * time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"36h", 36 * time.Hour},
+		{"1h30m", 90 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		age, err := ParseAge(tt.value)
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, age, tt.value)
+	}
+
+	for _, value := range []string{"", "d", "1.5d", "xd", "ten", "0d", "-3d", "-1h"} {
+		_, err := ParseAge(value)
+		require.ErrorIs(t, err, errInvalidAge, value)
+	}
+}
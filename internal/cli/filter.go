@@ -1,22 +1,61 @@
 package cli
 
-import "github.com/andreygrechin/gitree/internal/models"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andreygrechin/gitree/internal/models"
+)
 
 // FilterOptions configures repository filtering behavior.
 type FilterOptions struct {
-	ShowAll bool // When true, disables filtering (shows all repos including clean ones). Default: false.
+	ShowAll        bool          // When true, disables filtering (shows all repos including clean ones). Default: false.
+	StashOlderThan time.Duration // When set, keeps only repos with a stash older than this (applies with ShowAll too)
+	Stale          StaleFilter   // What to do with repos flagged stale (empty = StaleFlag; applies with ShowAll too)
+	Now            time.Time     // Time ages are measured from (zero = time.Now())
+}
+
+// StaleFilter selects what FilterRepositories does with stale repositories.
+type StaleFilter string
+
+const (
+	// StaleFlag keeps stale repositories like any other repository needing attention (default).
+	StaleFlag StaleFilter = "flag"
+	// StaleOnly keeps only stale repositories, e.g. to find abandoned clones.
+	StaleOnly StaleFilter = "only"
+	// StaleHide drops stale repositories.
+	StaleHide StaleFilter = "hide"
+)
+
+var errInvalidStaleFilter = errors.New("invalid stale filter")
+
+// ParseStaleFilter converts a filter name into a StaleFilter value.
+func ParseStaleFilter(value string) (StaleFilter, error) {
+	switch filter := StaleFilter(value); filter {
+	case StaleFlag, StaleOnly, StaleHide:
+		return filter, nil
+	default:
+		return "", fmt.Errorf("%w: %q (must be %s, %s or %s)", errInvalidStaleFilter, value, StaleFlag, StaleOnly, StaleHide)
+	}
 }
 
 // IsClean determines if a repository is in a clean state per FR-008.
 // A repository is considered clean if ALL of the following conditions are met:
-// 1. On main or master branch
+// 1. On the repository's default branch (main or master if it cannot be determined)
 // 2. No uncommitted changes
 // 3. No stashes
 // 4. Has remote tracking configured
-// 5. Not ahead of remote
-// 6. Not behind remote
-// 7. Not in detached HEAD state
-// 8. No error in status extraction
+// 5. Branch has an upstream, and the upstream still exists on the remote
+// 6. Not ahead of remote
+// 7. Not behind remote
+// 8. Not in detached HEAD state
+// 9. No merge, rebase, cherry-pick, revert, bisect or am in progress
+// 10. Not stale (HEAD or a local branch changed within the staleness threshold, if one is set)
+// 11. No other local branch with unpushed, unmerged work (if all branches were audited)
+// 12. No Git LFS files left as pointers and no LFS objects left unpushed
+// 13. Every submodule initialized, clean and checked out at the recorded commit
+// 14. No error in status extraction
 //
 // If any condition fails, the repository needs attention and is NOT clean.
 //
@@ -34,21 +73,36 @@ func IsClean(repo *models.Repository) bool {
 // FilterRepositories filters the repository list based on options.
 // By default (ShowAll=false), returns only repositories needing attention (not clean).
 // With ShowAll=true, returns all repositories unchanged.
+// StashOlderThan further narrows the result to repositories with forgotten stashes,
+// and Stale keeps only stale repositories or drops them.
 //
 // The function preserves the original order of repositories and does not
 // modify the input slice.
 func FilterRepositories(repos []*models.Repository, opts FilterOptions) []*models.Repository {
-	// If ShowAll is true, return all repositories unchanged
-	if opts.ShowAll {
+	// If ShowAll is true and no other filter is set, return all repositories unchanged
+	if opts.ShowAll && opts.StashOlderThan == 0 && (opts.Stale == "" || opts.Stale == StaleFlag) {
 		return repos
 	}
 
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
 	// Filter to show only repos needing attention (not clean)
 	filtered := make([]*models.Repository, 0, len(repos))
 	for _, repo := range repos {
-		if !IsClean(repo) {
-			filtered = append(filtered, repo)
+		if !opts.ShowAll && IsClean(repo) {
+			continue
+		}
+		if opts.StashOlderThan > 0 && (repo.GitStatus == nil || !repo.GitStatus.HasStashOlderThan(opts.StashOlderThan, now)) {
+			continue
+		}
+		stale := repo.GitStatus != nil && repo.GitStatus.IsStale
+		if (opts.Stale == StaleOnly && !stale) || (opts.Stale == StaleHide && stale) {
+			continue
 		}
+		filtered = append(filtered, repo)
 	}
 
 	return filtered
@@ -2,9 +2,11 @@ package cli
 
 import (
 	"testing"
+	"time"
 
 	"github.com/andreygrechin/gitree/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestIsClean_AllConditionsMet verifies clean state when all 9 conditions are satisfied.
@@ -111,6 +113,74 @@ func TestIsClean_NoRemote(t *testing.T) {
 	assert.False(t, IsClean(repo), "Repository without remote is not clean")
 }
 
+// TestIsClean_NoUpstream verifies a local-only branch (remote exists, never pushed) is not clean.
+func TestIsClean_NoUpstream(t *testing.T) {
+	repo := &models.Repository{
+		Path: "/test/repo",
+		Name: "repo",
+		GitStatus: &models.GitStatus{
+			Branch:     "main",
+			HasRemote:  true,
+			NoUpstream: true, // Never pushed with -u
+		},
+	}
+
+	assert.False(t, IsClean(repo), "Repository whose branch has no upstream is not clean")
+}
+
+// TestIsClean_UpstreamGone verifies a branch whose upstream was deleted is not clean.
+func TestIsClean_UpstreamGone(t *testing.T) {
+	repo := &models.Repository{
+		Path: "/test/repo",
+		Name: "repo",
+		GitStatus: &models.GitStatus{
+			Branch:       "main",
+			HasRemote:    true,
+			Upstream:     "origin/main",
+			UpstreamGone: true, // Deleted on the remote
+		},
+	}
+
+	assert.False(t, IsClean(repo), "Repository whose upstream is gone is not clean")
+}
+
+// TestIsClean_OperationInProgress verifies a repo with a stopped merge, rebase, etc. is never clean.
+func TestIsClean_OperationInProgress(t *testing.T) {
+	for _, op := range []models.Operation{
+		models.OperationMerge, models.OperationRebase, models.OperationCherryPick,
+		models.OperationRevert, models.OperationBisect, models.OperationAm,
+	} {
+		repo := &models.Repository{
+			Path: "/test/repo",
+			Name: "repo",
+			GitStatus: &models.GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				Upstream:  "origin/main",
+				Operation: op, // Otherwise clean, e.g. a bisect on main
+			},
+		}
+
+		assert.False(t, IsClean(repo), "Repository with %s in progress is not clean", op)
+	}
+}
+
+// TestIsClean_Stale verifies a stale repository needs attention even if otherwise clean.
+func TestIsClean_Stale(t *testing.T) {
+	repo := &models.Repository{
+		Path: "/test/repo",
+		Name: "repo",
+		GitStatus: &models.GitStatus{
+			Branch:       "main",
+			HasRemote:    true,
+			LastActivity: time.Now().Add(-365 * 24 * time.Hour),
+			IsStale:      true,
+		},
+	}
+
+	assert.False(t, IsClean(repo), "Repository should not be clean when it is stale")
+}
+
 // TestIsClean_AheadOfRemote verifies repo ahead of remote is not clean.
 func TestIsClean_AheadOfRemote(t *testing.T) {
 	repo := &models.Repository{
@@ -397,3 +467,84 @@ func TestFilterRepositories_NonModification(t *testing.T) {
 	assert.Len(t, filtered, 1, "Filtered slice should have 1 repo")
 	assert.Equal(t, "dirty", filtered[0].Name, "Filtered slice should contain dirty repo")
 }
+
+// TestFilterRepositories_StashOlderThan verifies only repos with old stashes are kept, with or without ShowAll.
+func TestFilterRepositories_StashOlderThan(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	stashed := func(name string, age time.Duration) *models.Repository {
+		return &models.Repository{
+			Path: "/test/" + name,
+			Name: name,
+			GitStatus: &models.GitStatus{
+				Branch: "main", HasRemote: true, HasStashes: true, StashCount: 1, OldestStash: now.Add(-age),
+			},
+		}
+	}
+	repos := []*models.Repository{
+		stashed("old", 60*24*time.Hour),
+		stashed("recent", time.Hour),
+		{Path: "/test/none", Name: "none", GitStatus: &models.GitStatus{Branch: "feature", HasRemote: true}},
+		{Path: "/test/unknown", Name: "unknown"},
+	}
+
+	for _, showAll := range []bool{false, true} {
+		filtered := FilterRepositories(repos, FilterOptions{ShowAll: showAll, StashOlderThan: 30 * 24 * time.Hour, Now: now})
+
+		require.Len(t, filtered, 1)
+		assert.Equal(t, "old", filtered[0].Name)
+	}
+}
+
+func TestFilterRepositories_Stale(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	repos := []*models.Repository{
+		{Path: "/test/abandoned", Name: "abandoned", GitStatus: &models.GitStatus{
+			Branch: "main", HasRemote: true, LastActivity: now.Add(-200 * 24 * time.Hour), IsStale: true,
+		}},
+		{Path: "/test/active", Name: "active", GitStatus: &models.GitStatus{
+			Branch: "feature", HasRemote: true, LastActivity: now.Add(-time.Hour),
+		}},
+		{Path: "/test/clean", Name: "clean", GitStatus: &models.GitStatus{
+			Branch: "main", HasRemote: true, LastActivity: now.Add(-time.Hour),
+		}},
+		{Path: "/test/unknown", Name: "unknown"},
+	}
+
+	names := func(repos []*models.Repository) []string {
+		var result []string
+		for _, repo := range repos {
+			result = append(result, repo.Name)
+		}
+
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		opts     FilterOptions
+		expected []string
+	}{
+		{"flag", FilterOptions{Stale: StaleFlag}, []string{"abandoned", "active", "unknown"}},
+		{"only", FilterOptions{Stale: StaleOnly}, []string{"abandoned"}},
+		{"only with all", FilterOptions{ShowAll: true, Stale: StaleOnly}, []string{"abandoned"}},
+		{"hide", FilterOptions{Stale: StaleHide}, []string{"active", "unknown"}},
+		{"hide with all", FilterOptions{ShowAll: true, Stale: StaleHide}, []string{"active", "clean", "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, names(FilterRepositories(repos, tt.opts)))
+		})
+	}
+}
+
+func TestParseStaleFilter(t *testing.T) {
+	for _, value := range []string{"flag", "only", "hide"} {
+		filter, err := ParseStaleFilter(value)
+		require.NoError(t, err)
+		assert.Equal(t, StaleFilter(value), filter)
+	}
+
+	_, err := ParseStaleFilter("keep")
+	require.ErrorIs(t, err, errInvalidStaleFilter)
+}
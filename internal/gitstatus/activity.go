@@ -0,0 +1,40 @@
+package gitstatus
+
+import (
+	"time"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// extractLastActivity records when HEAD or any local branch last changed, as the
+// most recent committer date among their commits, and flags the repository as
+// stale if that is longer than staleAfter ago (0 disables the check).
+func extractLastActivity(repo *git.Repository, status *models.GitStatus, staleAfter time.Duration, now time.Time) {
+	var latest time.Time
+	consider := func(hash plumbing.Hash) {
+		commit, err := repo.CommitObject(hash)
+		if err != nil {
+			return // Not a commit, or a missing object: says nothing about activity
+		}
+		if commit.Committer.When.After(latest) {
+			latest = commit.Committer.When
+		}
+	}
+
+	if head, err := repo.Head(); err == nil {
+		consider(head.Hash())
+	}
+
+	if branches, err := repo.Branches(); err == nil {
+		_ = branches.ForEach(func(ref *plumbing.Reference) error {
+			consider(ref.Hash())
+
+			return nil
+		})
+	}
+
+	status.LastActivity = latest
+	status.IsStale = staleAfter > 0 && status.InactiveFor(staleAfter, now)
+}
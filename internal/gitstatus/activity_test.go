@@ -0,0 +1,89 @@
+package gitstatus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// createRepoWithCommitDates creates a repository with a commit on main and one on
+// a second branch, made the given durations ago.
+func createRepoWithCommitDates(t *testing.T, mainAge, branchAge time.Duration) string {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commit := func(file string, age time.Duration) {
+		require.NoError(t, os.WriteFile(filepath.Join(tempDir, file), []byte(file), 0o600))
+		_, err := worktree.Add(file)
+		require.NoError(t, err)
+		sig := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now().Add(-age)}
+		_, err = worktree.Commit("Add "+file, &git.CommitOptions{Author: sig, Committer: sig})
+		require.NoError(t, err)
+	}
+
+	commit("main.txt", mainAge)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("side"), Create: true}))
+	commit("side.txt", branchAge)
+
+	// Back on the first branch
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()}))
+
+	return tempDir
+}
+
+func TestExtract_LastActivityIncludesLocalBranches(t *testing.T) {
+	day := 24 * time.Hour
+	repoPath := createRepoWithCommitDates(t, 200*day, 10*day)
+
+	opts := DefaultOptions()
+	opts.StaleAfter = 90 * day
+	status, err := Extract(context.Background(), repoPath, opts, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-10*day), status.LastActivity, time.Minute,
+		"a recent commit on another local branch counts as activity")
+	assert.False(t, status.IsStale)
+}
+
+func TestExtract_FlagsStaleRepositories(t *testing.T) {
+	day := 24 * time.Hour
+	repoPath := createRepoWithCommitDates(t, 200*day, 120*day)
+
+	tests := []struct {
+		name       string
+		staleAfter time.Duration
+		stale      bool
+	}{
+		{"disabled", 0, false},
+		{"older than threshold", 90 * day, true},
+		{"newer than threshold", 150 * day, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.StaleAfter = tt.staleAfter
+			status, err := Extract(context.Background(), repoPath, opts, []gitignore.Pattern{})
+
+			require.NoError(t, err)
+			assert.WithinDuration(t, time.Now().Add(-120*day), status.LastActivity, time.Minute)
+			assert.Equal(t, tt.stale, status.IsStale)
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package gitstatus
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	commitgraphfmt "github.com/go-git/go-git/v5/plumbing/format/commitgraph/v2"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+)
+
+// Sides of the comparison a commit is reachable from.
+const (
+	sideLocal uint8 = 1 << iota
+	sideUpstream
+	sideBoth = sideLocal | sideUpstream
+)
+
+// commitNodeIndex returns the commits of a repository, read from its commit-graph
+// file when it has one. The returned closer, if not nil, releases the file.
+func commitNodeIndex(repo *git.Repository) (commitgraph.CommitNodeIndex, io.Closer) {
+	if fs, ok := gitDirFilesystem(repo); ok {
+		if index, err := commitgraphfmt.OpenChainOrFileIndex(fs); err == nil {
+			return commitgraph.NewGraphCommitNodeIndex(index, repo.Storer), index
+		}
+	}
+
+	return commitgraph.NewObjectCommitNodeIndex(repo.Storer), nil
+}
+
+// countAheadBehind counts the commits reachable from local but not from upstream
+// (ahead) and the other way round (behind), like "git rev-list --left-right --count".
+//
+// Both histories are walked together, newest first, marking each commit with the
+// sides it is reachable from. The walk stops once every commit left to visit is
+// reachable from both sides, i.e. at the merge base, so the cost depends on how far
+// the branches diverged rather than on the length of the history. Generation numbers
+// from the commit-graph make the result exact; without them the walk, like git's,
+// relies on commit dates and goes on while queued commits are newer than the oldest
+// commit counted, which covers all but badly skewed clocks. The walk stops with
+// ctx's error once ctx is done.
+func countAheadBehind(
+	ctx context.Context, index commitgraph.CommitNodeIndex, local, upstream plumbing.Hash,
+) (ahead, behind int, err error) {
+	if local == upstream {
+		return 0, 0, nil
+	}
+
+	w, err := walkDivergence(ctx, index, local, upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return w.ahead, w.behind, nil
+}
+
+// walkDivergence walks the commits reachable from local or any of upstreams as
+// countAheadBehind describes, and returns the walk: commits reachable from local
+// only are those counted for sideLocal. Without upstreams, all of local's history
+// is walked.
+func walkDivergence(
+	ctx context.Context, index commitgraph.CommitNodeIndex, local plumbing.Hash, upstreams ...plumbing.Hash,
+) (*aheadBehindWalk, error) {
+	w := &aheadBehindWalk{
+		flags:   make(map[plumbing.Hash]uint8),
+		counted: make(map[plumbing.Hash]uint8),
+		queued:  make(map[plumbing.Hash]bool),
+	}
+
+	type tip struct {
+		hash plumbing.Hash
+		side uint8
+	}
+	tips := []tip{{local, sideLocal}}
+	for _, upstream := range upstreams {
+		tips = append(tips, tip{upstream, sideUpstream})
+	}
+	for _, tip := range tips {
+		node, err := index.Get(tip.hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", tip.hash, err)
+		}
+		w.mark(node, tip.side)
+	}
+
+	for w.active > 0 || (w.queue.Len() > 0 && !w.oldest.IsZero() && !w.queue[0].CommitTime().Before(w.oldest)) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		node, ok := heap.Pop(&w.queue).(commitgraph.CommitNode)
+		if !ok {
+			break
+		}
+
+		hash := node.ID()
+		if w.queued[hash] {
+			w.active--
+		}
+		delete(w.queued, hash)
+		side := w.flags[hash]
+		w.count(node, side)
+
+		for i := range node.NumParents() {
+			parent, err := node.ParentNode(i)
+			if err != nil {
+				if errors.Is(err, plumbing.ErrObjectNotFound) {
+					continue // Shallow clone boundary
+				}
+
+				return nil, fmt.Errorf("failed to read parent of %s: %w", hash, err)
+			}
+			w.mark(parent, side)
+		}
+	}
+
+	return w, nil
+}
+
+// aheadBehindWalk is the state of a countAheadBehind walk.
+type aheadBehindWalk struct {
+	queue   commitQueue
+	flags   map[plumbing.Hash]uint8 // Sides each commit seen so far is reachable from
+	counted map[plumbing.Hash]uint8 // Side each commit was counted for when last visited
+	queued  map[plumbing.Hash]bool  // Commits waiting in the queue, and whether they are active
+	active  int                     // Queued commits not reachable from both sides or counted wrongly
+	oldest  time.Time               // Commit date of the oldest commit counted for one side
+	ahead   int
+	behind  int
+}
+
+// mark records that a commit is reachable from side and queues it if that is new.
+// A commit visited already is queued again so its count and ancestors are corrected;
+// this only happens when commit dates are skewed and no commit-graph orders the walk.
+func (w *aheadBehindWalk) mark(node commitgraph.CommitNode, side uint8) {
+	hash := node.ID()
+	old := w.flags[hash]
+	flags := old | side
+	if flags == old {
+		return
+	}
+	w.flags[hash] = flags
+
+	// Commits reachable from both sides only matter if they were counted for one
+	// side before and must be corrected
+	active := flags != sideBoth || w.counted[hash] != 0
+
+	if active, queued := w.queued[hash]; queued {
+		if active && flags == sideBoth && w.counted[hash] == 0 {
+			w.queued[hash] = false
+			w.active--
+		}
+
+		return
+	}
+
+	w.queued[hash] = active
+	heap.Push(&w.queue, node)
+	if active {
+		w.active++
+	}
+}
+
+// count updates the totals for a visited commit reachable from side.
+func (w *aheadBehindWalk) count(node commitgraph.CommitNode, side uint8) {
+	hash := node.ID()
+	prev := w.counted[hash]
+	if prev == side {
+		return
+	}
+	w.counted[hash] = side
+
+	switch prev {
+	case sideLocal:
+		w.ahead--
+	case sideUpstream:
+		w.behind--
+	}
+	switch side {
+	case sideLocal:
+		w.ahead++
+	case sideUpstream:
+		w.behind++
+	}
+
+	if side != sideBoth && (w.oldest.IsZero() || node.CommitTime().Before(w.oldest)) {
+		w.oldest = node.CommitTime()
+	}
+}
+
+// commitQueue is a priority queue handing out descendants before their ancestors:
+// by generation number where the commit-graph provides one, then by commit date.
+type commitQueue []commitgraph.CommitNode
+
+func (q commitQueue) Len() int { return len(q) }
+
+func (q commitQueue) Less(i, j int) bool {
+	// Commits missing from the commit-graph report the maximum generation, which
+	// is right: they were added after the graph was written
+	if gi, gj := q[i].Generation(), q[j].Generation(); gi != gj {
+		return gi > gj
+	}
+
+	return q[i].CommitTime().After(q[j].CommitTime())
+}
+
+func (q commitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *commitQueue) Push(x any) {
+	if node, ok := x.(commitgraph.CommitNode); ok {
+		*q = append(*q, node)
+	}
+}
+
+func (q *commitQueue) Pop() any {
+	old := *q
+	node := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+
+	return node
+}
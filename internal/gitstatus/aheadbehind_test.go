@@ -0,0 +1,331 @@
+package gitstatus
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	commitgraphfmt "github.com/go-git/go-git/v5/plumbing/format/commitgraph/v2"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// history builds commit graphs directly in an object store, together with the
+// commit-graph index git would write for them.
+type history struct {
+	store      storer.EncodedObjectStorer
+	graph      *commitgraphfmt.MemoryIndex
+	generation map[plumbing.Hash]uint64
+	count      int
+}
+
+func newHistory(store storer.EncodedObjectStorer) *history {
+	return &history{
+		store:      store,
+		graph:      commitgraphfmt.NewMemoryIndex(),
+		generation: make(map[plumbing.Hash]uint64),
+	}
+}
+
+// commit stores a commit with the given parents and committer date.
+func (h *history) commit(tb testing.TB, when time.Time, parents ...plumbing.Hash) plumbing.Hash {
+	tb.Helper()
+
+	h.count++
+	sig := object.Signature{Name: "Test User", Email: "test@example.com", When: when}
+	c := &object.Commit{
+		Author:       sig,
+		Committer:    sig,
+		Message:      fmt.Sprintf("commit %d", h.count),
+		TreeHash:     plumbing.ZeroHash,
+		ParentHashes: parents,
+	}
+
+	obj := h.store.NewEncodedObject()
+	require.NoError(tb, c.Encode(obj))
+	hash, err := h.store.SetEncodedObject(obj)
+	require.NoError(tb, err)
+
+	var generation uint64
+	for _, parent := range parents {
+		generation = max(generation, h.generation[parent])
+	}
+	h.generation[hash] = generation + 1
+	h.graph.Add(hash, &commitgraphfmt.CommitData{
+		TreeHash:     plumbing.ZeroHash,
+		ParentHashes: parents,
+		Generation:   generation + 1,
+		When:         when,
+	})
+
+	return hash
+}
+
+// chain stores n commits on top of parent, one minute apart, and returns the last one.
+func (h *history) chain(tb testing.TB, parent plumbing.Hash, start time.Time, n int) plumbing.Hash {
+	tb.Helper()
+
+	tip := parent
+	for i := range n {
+		if tip.IsZero() {
+			tip = h.commit(tb, start)
+		} else {
+			tip = h.commit(tb, start.Add(time.Duration(i)*time.Minute), tip)
+		}
+	}
+
+	return tip
+}
+
+// namedIndex is a way countAheadBehind can read the history.
+type namedIndex struct {
+	name  string
+	index commitgraph.CommitNodeIndex
+}
+
+// indexes returns the history read from the object store and from the commit-graph.
+func (h *history) indexes() []namedIndex {
+	return []namedIndex{
+		{"objects", commitgraph.NewObjectCommitNodeIndex(h.store)},
+		{"commit-graph", commitgraph.NewGraphCommitNodeIndex(h.graph, h.store)},
+	}
+}
+
+// countFullWalk counts commits reachable from 'from' that are not reachable from
+// 'to' by listing both histories completely.
+func countFullWalk(tb testing.TB, index commitgraph.CommitNodeIndex, from, to plumbing.Hash) int {
+	tb.Helper()
+
+	reachable := func(tip plumbing.Hash) map[plumbing.Hash]bool {
+		seen := map[plumbing.Hash]bool{tip: true}
+		queue := []plumbing.Hash{tip}
+		for len(queue) > 0 {
+			node, err := index.Get(queue[0])
+			require.NoError(tb, err)
+			queue = queue[1:]
+			for _, parent := range node.ParentHashes() {
+				if !seen[parent] {
+					seen[parent] = true
+					queue = append(queue, parent)
+				}
+			}
+		}
+
+		return seen
+	}
+
+	excluded := reachable(to)
+	count := 0
+	for hash := range reachable(from) {
+		if !excluded[hash] {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestCountAheadBehind(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := newHistory(memory.NewStorage())
+
+	base := h.chain(t, plumbing.ZeroHash, start, 20)
+	local := h.chain(t, base, start.Add(time.Hour), 3)
+	upstream := h.chain(t, base, start.Add(2*time.Hour), 2)
+
+	// Local merged upstream, then committed once more
+	merged := h.commit(t, start.Add(3*time.Hour), local, upstream)
+	afterMerge := h.commit(t, start.Add(4*time.Hour), merged)
+
+	// Commit dates going backwards (a skewed clock) must not change the counts
+	skewed := h.chain(t, base, start.Add(-time.Hour), 4)
+	skewedUpstream := h.commit(t, start.Add(5*time.Hour), skewed)
+
+	// Criss-cross merges: two merge bases
+	left := h.commit(t, start.Add(6*time.Hour), local, upstream)
+	right := h.commit(t, start.Add(6*time.Hour), upstream, local)
+	leftTip := h.commit(t, start.Add(7*time.Hour), left)
+
+	// Unrelated histories
+	orphan := h.chain(t, plumbing.ZeroHash, start, 5)
+
+	tests := []struct {
+		name          string
+		local         plumbing.Hash
+		upstream      plumbing.Hash
+		ahead, behind int
+	}{
+		{name: "same commit", local: local, upstream: local},
+		{name: "ahead only", local: local, upstream: base, ahead: 3},
+		{name: "behind only", local: base, upstream: upstream, behind: 2},
+		{name: "diverged", local: local, upstream: upstream, ahead: 3, behind: 2},
+		{name: "merged upstream", local: afterMerge, upstream: upstream, ahead: 5},
+		{name: "skewed dates", local: local, upstream: skewedUpstream, ahead: 3, behind: 5},
+		{name: "criss-cross", local: leftTip, upstream: right, ahead: 2, behind: 1},
+		{name: "unrelated", local: orphan, upstream: upstream, ahead: 5, behind: 22},
+	}
+
+	for _, idx := range h.indexes() {
+		for _, tt := range tests {
+			t.Run(idx.name+"/"+tt.name, func(t *testing.T) {
+				ahead, behind, err := countAheadBehind(context.Background(), idx.index, tt.local, tt.upstream)
+				require.NoError(t, err)
+				assert.Equal(t, tt.ahead, ahead, "ahead")
+				assert.Equal(t, tt.behind, behind, "behind")
+			})
+		}
+	}
+}
+
+// randomHistory stores a history of n commits with random merges. With skew, commit
+// dates are up to a day off; otherwise commits are never older than their parents.
+func randomHistory(t *testing.T, rng *rand.Rand, n int, skew bool) (*history, []plumbing.Hash) {
+	t.Helper()
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := newHistory(memory.NewStorage())
+
+	commits := []plumbing.Hash{h.commit(t, start)}
+	for i := 1; i < n; i++ {
+		when := start.Add(time.Duration(i) * time.Hour)
+		if skew {
+			when = when.Add(time.Duration(rng.IntN(48)-24) * time.Hour)
+		}
+		parents := []plumbing.Hash{commits[len(commits)-1-rng.IntN(min(len(commits), 10))]}
+		if rng.IntN(5) == 0 {
+			parents = append(parents, commits[rng.IntN(len(commits))])
+		}
+		commits = append(commits, h.commit(t, when, parents...))
+	}
+
+	return h, commits
+}
+
+func TestCountAheadBehind_MatchesFullWalk(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2)) //nolint:gosec // Deterministic test data
+
+	for _, skew := range []bool{false, true} {
+		h, commits := randomHistory(t, rng, 300, skew)
+
+		for _, idx := range h.indexes() {
+			// Only generation numbers make the walk exact whatever the commit dates
+			if skew && idx.name != "commit-graph" {
+				continue
+			}
+
+			for range 200 {
+				local := commits[rng.IntN(len(commits))]
+				upstream := commits[rng.IntN(len(commits))]
+
+				ahead, behind, err := countAheadBehind(context.Background(), idx.index, local, upstream)
+				require.NoError(t, err)
+				require.Equal(t, countFullWalk(t, idx.index, local, upstream), ahead,
+					"%s (skew %t): ahead of %s vs %s", idx.name, skew, local, upstream)
+				require.Equal(t, countFullWalk(t, idx.index, upstream, local), behind,
+					"%s (skew %t): behind of %s vs %s", idx.name, skew, local, upstream)
+			}
+		}
+	}
+}
+
+func TestCountAheadBehind_MissingCommit(t *testing.T) {
+	h := newHistory(memory.NewStorage())
+	tip := h.commit(t, time.Now())
+
+	missing := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	_, _, err := countAheadBehind(context.Background(), commitgraph.NewObjectCommitNodeIndex(h.store), tip, missing)
+	assert.Error(t, err)
+}
+
+func TestCountAheadBehind_StopsWhenContextIsDone(t *testing.T) {
+	h := newHistory(memory.NewStorage())
+	base := h.commit(t, time.Now().Add(-time.Hour))
+	local := h.chain(t, base, time.Now().Add(-30*time.Minute), 10)
+	upstream := h.chain(t, base, time.Now().Add(-20*time.Minute), 10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err := countAheadBehind(ctx, commitgraph.NewObjectCommitNodeIndex(h.store), local, upstream)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestCommitNodeIndex_ReadsCommitGraphFile(t *testing.T) {
+	tempDir := t.TempDir()
+	repo, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := newHistory(repo.Storer)
+	base := h.chain(t, plumbing.ZeroHash, start, 10)
+	local := h.chain(t, base, start.Add(time.Hour), 2)
+	upstream := h.chain(t, base, start.Add(2*time.Hour), 3)
+
+	// Without a commit-graph file commits are read from the object store
+	_, closer := commitNodeIndex(repo)
+	assert.Nil(t, closer)
+
+	graphFile := filepath.Join(tempDir, ".git", "objects", "info", "commit-graph")
+	require.NoError(t, os.MkdirAll(filepath.Dir(graphFile), 0o750))
+	f, err := os.Create(graphFile)
+	require.NoError(t, err)
+	require.NoError(t, commitgraphfmt.NewEncoder(f).Encode(h.graph))
+	require.NoError(t, f.Close())
+
+	index, closer := commitNodeIndex(repo)
+	require.NotNil(t, closer)
+	defer func() {
+		_ = closer.Close()
+	}()
+
+	node, err := index.Get(local)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(12), node.Generation(), "generation should come from the commit-graph")
+
+	ahead, behind, err := countAheadBehind(context.Background(), index, local, upstream)
+	require.NoError(t, err)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 3, behind)
+}
+
+// BenchmarkAheadBehind compares counting by full history walks with the bounded
+// walk on long histories where the branches diverged a few commits ago.
+func BenchmarkAheadBehind(b *testing.B) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, length := range []int{1000, 10000} {
+		h := newHistory(memory.NewStorage())
+		base := h.chain(b, plumbing.ZeroHash, start, length)
+		local := h.chain(b, base, start.Add(time.Duration(length)*time.Minute), 3)
+		upstream := h.chain(b, base, start.Add(time.Duration(length+10)*time.Minute), 5)
+
+		objects := commitgraph.NewObjectCommitNodeIndex(h.store)
+
+		b.Run(fmt.Sprintf("commits=%d/full-walk", length), func(b *testing.B) {
+			for b.Loop() {
+				countFullWalk(b, objects, local, upstream)
+				countFullWalk(b, objects, upstream, local)
+			}
+		})
+
+		for _, idx := range h.indexes() {
+			b.Run(fmt.Sprintf("commits=%d/%s", length, idx.name), func(b *testing.B) {
+				for b.Loop() {
+					if _, _, err := countAheadBehind(context.Background(), idx.index, local, upstream); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// Backend computes the status of a repository's working tree, by far the most
+// expensive part of status extraction. Everything else is read with go-git.
+type Backend interface {
+	// WorktreeStatus returns the files of the working tree at worktreePath that differ
+	// from the index or HEAD. ignorePatterns are the user's global excludes, for
+	// implementations that do not read them themselves.
+	WorktreeStatus(
+		ctx context.Context, repo *git.Repository, worktreePath string, ignorePatterns []gitignore.Pattern,
+	) (git.Status, error)
+}
+
+// BackendName selects a Backend implementation.
+type BackendName string
+
+const (
+	// BackendGoGit computes the status in-process with go-git (default).
+	BackendGoGit BackendName = "go-git"
+	// BackendGit runs "git status --porcelain=v2", which is much faster on large working
+	// trees and honors fsmonitor, the untracked cache, sparse checkouts and core.excludesFile.
+	BackendGit BackendName = "git"
+	// BackendAuto uses git if it is installed, and go-git otherwise or where git fails.
+	BackendAuto BackendName = "auto"
+)
+
+var (
+	errInvalidBackend = errors.New("invalid backend")
+	errGitNotFound    = errors.New("git executable not found")
+)
+
+// ParseBackend converts a backend name into a BackendName value.
+func ParseBackend(value string) (BackendName, error) {
+	switch name := BackendName(value); name {
+	case BackendGoGit, BackendGit, BackendAuto:
+		return name, nil
+	default:
+		return "", fmt.Errorf("%w: %q (must be %s, %s or %s)", errInvalidBackend, value, BackendGoGit, BackendGit, BackendAuto)
+	}
+}
+
+// lookPathGit finds the git executable once per process.
+var lookPathGit = sync.OnceValues(func() (string, error) { //nolint:gochecknoglobals // Cached PATH lookup
+	return exec.LookPath("git")
+})
+
+// newBackend returns the Backend selected by name.
+func newBackend(name BackendName) Backend {
+	switch name {
+	case BackendGit:
+		gitPath, err := lookPathGit()
+		if err != nil {
+			return unavailableBackend{err: fmt.Errorf("%w: %w", errGitNotFound, err)}
+		}
+
+		return gitCLIBackend{gitPath: gitPath}
+	case BackendAuto:
+		if gitPath, err := lookPathGit(); err == nil {
+			return fallbackBackend{primary: gitCLIBackend{gitPath: gitPath}, fallback: goGitBackend{}}
+		}
+
+		return goGitBackend{}
+	default:
+		return goGitBackend{}
+	}
+}
+
+// backendLabel describes the backend newBackend picks for name, for debug output.
+func backendLabel(name BackendName) string {
+	switch name {
+	case "":
+		return string(BackendGoGit)
+	case BackendAuto:
+		if _, err := lookPathGit(); err != nil {
+			return "auto (go-git, git not found)"
+		}
+
+		return "auto (git, go-git where git fails)"
+	default:
+		return string(name)
+	}
+}
+
+// goGitBackend computes the working tree status with go-git.
+type goGitBackend struct{}
+
+func (goGitBackend) WorktreeStatus(
+	ctx context.Context, repo *git.Repository, _ string, ignorePatterns []gitignore.Pattern,
+) (git.Status, error) {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	// go-git takes no context: stop its walk of the working tree through the filesystem
+	worktree.Filesystem = contextFilesystem{Filesystem: worktree.Filesystem, ctx: ctx}
+
+	// Add pre-loaded global gitignore patterns to align with native git behavior
+	worktree.Excludes = append(worktree.Excludes, ignorePatterns...)
+
+	wtStatus, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	// go-git does not report conflicts
+	markUnmerged(repo, wtStatus)
+
+	return wtStatus, nil
+}
+
+// contextFilesystem fails reads with ctx's error once ctx is done.
+type contextFilesystem struct {
+	billy.Filesystem
+	ctx context.Context //nolint:containedctx // go-git reads the working tree through a billy.Filesystem only
+}
+
+func (fs contextFilesystem) ReadDir(path string) ([]os.FileInfo, error) {
+	if err := fs.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return fs.Filesystem.ReadDir(path)
+}
+
+func (fs contextFilesystem) Lstat(filename string) (os.FileInfo, error) {
+	if err := fs.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return fs.Filesystem.Lstat(filename)
+}
+
+func (fs contextFilesystem) Open(filename string) (billy.File, error) {
+	if err := fs.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return fs.Filesystem.Open(filename)
+}
+
+// fallbackBackend uses primary, and fallback for repositories primary fails on
+// (e.g. git refusing a repository owned by another user).
+type fallbackBackend struct {
+	primary  Backend
+	fallback Backend
+}
+
+func (b fallbackBackend) WorktreeStatus(
+	ctx context.Context, repo *git.Repository, worktreePath string, ignorePatterns []gitignore.Pattern,
+) (git.Status, error) {
+	wtStatus, err := b.primary.WorktreeStatus(ctx, repo, worktreePath, ignorePatterns)
+	if err != nil && ctx.Err() == nil {
+		return b.fallback.WorktreeStatus(ctx, repo, worktreePath, ignorePatterns)
+	}
+
+	return wtStatus, err
+}
+
+// unavailableBackend fails every status request, e.g. when git is not installed.
+type unavailableBackend struct {
+	err error
+}
+
+func (b unavailableBackend) WorktreeStatus(context.Context, *git.Repository, string, []gitignore.Pattern) (git.Status, error) {
+	return nil, b.err
+}
@@ -0,0 +1,280 @@
+package gitstatus
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireGit skips the test if git is not installed.
+func requireGit(t *testing.T) {
+	t.Helper()
+
+	if _, err := lookPathGit(); err != nil {
+		t.Skip("git is not installed")
+	}
+}
+
+// runGit runs git in dir with a fixed identity and no system configuration.
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test User", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test User", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %v: %s", args, out)
+
+	return string(out)
+}
+
+// writeFile writes a file of a fixture repository, creating its directories.
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+// newFixtureRepo creates a repository with a few committed files using git itself.
+func newFixtureRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "--quiet", "--initial-branch=main")
+	writeFile(t, dir, "README.md", "readme\n")
+	writeFile(t, dir, "src/main.go", "package main\n")
+	writeFile(t, dir, "src/util.go", "package main\n\nfunc util() {}\n")
+	writeFile(t, dir, "docs/guide.md", "guide\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Initial commit")
+
+	return dir
+}
+
+// categorizedFiles maps each changed file to the change categories it counts towards.
+func categorizedFiles(wtStatus git.Status) map[string][]changeCategory {
+	files := make(map[string][]changeCategory)
+	for path, fileStatus := range wtStatus {
+		if categories := categorizeChange(fileStatus); len(categories) > 0 {
+			files[path] = categories
+		}
+	}
+
+	return files
+}
+
+func TestBackends_Parity(t *testing.T) {
+	requireGit(t)
+
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, dir string)
+	}{
+		{name: "clean", setup: func(*testing.T, string) {}},
+		{
+			name: "modified and deleted",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				writeFile(t, dir, "src/main.go", "package main\n\nfunc main() {}\n")
+				require.NoError(t, os.Remove(filepath.Join(dir, "docs/guide.md")))
+			},
+		},
+		{
+			name: "staged, then modified again",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				writeFile(t, dir, "README.md", "staged\n")
+				writeFile(t, dir, "src/new.go", "package main\n")
+				runGit(t, dir, "add", "README.md", "src/new.go")
+				runGit(t, dir, "rm", "--quiet", "src/util.go")
+				writeFile(t, dir, "README.md", "staged, then changed\n")
+			},
+		},
+		{
+			name: "untracked and ignored",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				writeFile(t, dir, ".gitignore", "*.log\nbuild/\n")
+				writeFile(t, dir, "notes.txt", "notes\n")
+				writeFile(t, dir, "scratch/a/one.txt", "1\n")
+				writeFile(t, dir, "scratch/b/two.txt", "2\n")
+				writeFile(t, dir, "debug.log", "ignored\n")
+				writeFile(t, dir, "build/out.bin", "ignored\n")
+			},
+		},
+		{
+			name: "global excludes file",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				excludes := filepath.Join(os.Getenv("HOME"), "global-ignore")
+				require.NoError(t, os.WriteFile(excludes, []byte("*.swp\n"), 0o600))
+				runGit(t, dir, "config", "--global", "core.excludesFile", excludes)
+				writeFile(t, dir, "src/.main.go.swp", "swap\n")
+				writeFile(t, dir, "todo.txt", "todo\n")
+			},
+		},
+		{
+			name: "merge conflict",
+			setup: func(t *testing.T, dir string) {
+				t.Helper()
+				runGit(t, dir, "checkout", "--quiet", "-b", "other")
+				writeFile(t, dir, "README.md", "other side\n")
+				writeFile(t, dir, "src/util.go", "package main\n\nfunc util() { other() }\n")
+				runGit(t, dir, "commit", "--quiet", "-am", "Other side")
+				runGit(t, dir, "checkout", "--quiet", "main")
+				writeFile(t, dir, "README.md", "main side\n")
+				runGit(t, dir, "commit", "--quiet", "-am", "Main side")
+				cmd := exec.Command("git", "merge", "--quiet", "other")
+				cmd.Dir = dir
+				_ = cmd.Run() // Fails with a conflict on README.md, merges util.go
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateUserConfig(t)
+			dir := newFixtureRepo(t)
+			tt.setup(t, dir)
+
+			repo, err := git.PlainOpen(dir)
+			require.NoError(t, err)
+			ignorePatterns, _ := loadGlobalIgnorePatterns(osfs.New("/"), DefaultOptions()) // None without an excludes file
+
+			goGitStatus, err := newBackend(BackendGoGit).WorktreeStatus(context.Background(), repo, dir, ignorePatterns)
+			require.NoError(t, err)
+			gitStatus, err := newBackend(BackendGit).WorktreeStatus(context.Background(), repo, dir, ignorePatterns)
+			require.NoError(t, err)
+
+			assert.Equal(t, categorizedFiles(goGitStatus), categorizedFiles(gitStatus))
+			assert.Equal(t, countChanges(goGitStatus), countChanges(gitStatus))
+			assert.Equal(t, goGitStatus.IsClean(), gitStatus.IsClean())
+		})
+	}
+}
+
+// go-git has no rename detection: a staged rename is a new file and a deleted one.
+func TestBackends_GitDetectsRenames(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+
+	dir := newFixtureRepo(t)
+	runGit(t, dir, "mv", "docs/guide.md", "docs/manual.md")
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+
+	gitStatus, err := newBackend(BackendGit).WorktreeStatus(context.Background(), repo, dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeCounts{Renamed: 1}, countChanges(gitStatus))
+	assert.Equal(t, "docs/guide.md", gitStatus["docs/manual.md"].Extra)
+
+	goGitStatus, err := newBackend(BackendGoGit).WorktreeStatus(context.Background(), repo, dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeCounts{Staged: 2}, countChanges(goGitStatus))
+}
+
+func TestParsePorcelainV2(t *testing.T) {
+	hashes := "0000000000000000000000000000000000000000 1111111111111111111111111111111111111111"
+	out := strings.Join([]string{
+		"# branch.oid " + "1111111111111111111111111111111111111111",
+		"1 .M N... 100644 100644 100644 " + hashes + " src/main.go",
+		"1 MM N... 100644 100644 100644 " + hashes + " README.md",
+		"1 A. N... 000000 100644 100644 " + hashes + " new file.txt",
+		"1 .T N... 100644 120000 120000 " + hashes + " link",
+		"2 R. N... 100644 100644 100644 " + hashes + " R100 docs/manual.md", "docs/guide.md",
+		"u UU N... 100644 100644 100644 100644 " + hashes + " 2222222222222222222222222222222222222222 conflict.txt",
+		"? scratch/notes.txt",
+		"! build/out.bin",
+		"",
+	}, "\x00")
+
+	status, err := parsePorcelainV2([]byte(out))
+	require.NoError(t, err)
+
+	assert.Equal(t, git.Status{
+		"src/main.go":       {Staging: git.Unmodified, Worktree: git.Modified},
+		"README.md":         {Staging: git.Modified, Worktree: git.Modified},
+		"new file.txt":      {Staging: git.Added, Worktree: git.Unmodified},
+		"link":              {Staging: git.Unmodified, Worktree: git.Modified},
+		"docs/manual.md":    {Staging: git.Renamed, Worktree: git.Unmodified, Extra: "docs/guide.md"},
+		"conflict.txt":      {Staging: git.UpdatedButUnmerged, Worktree: git.UpdatedButUnmerged},
+		"scratch/notes.txt": {Staging: git.Untracked, Worktree: git.Untracked},
+	}, status)
+}
+
+func TestParsePorcelainV2_RejectsUnknownRecords(t *testing.T) {
+	for _, out := range []string{"x something\x00", "1 M\x00", "2 R. N... 1 2 3 a b R100 only-new-path"} {
+		_, err := parsePorcelainV2([]byte(out))
+		require.ErrorIs(t, err, errPorcelainFormat, "%q", out)
+	}
+}
+
+func TestParseBackend(t *testing.T) {
+	for _, value := range []string{"go-git", "git", "auto"} {
+		name, err := ParseBackend(value)
+		require.NoError(t, err)
+		assert.Equal(t, BackendName(value), name)
+	}
+
+	_, err := ParseBackend("libgit2")
+	require.ErrorIs(t, err, errInvalidBackend)
+}
+
+func TestExtract_WithGitBackend(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+
+	dir := newFixtureRepo(t)
+	writeFile(t, dir, "src/main.go", "package main\n\nfunc main() {}\n")
+	writeFile(t, dir, "todo.txt", "todo\n")
+
+	opts := DefaultOptions()
+	opts.Backend = BackendGit
+	status, err := Extract(context.Background(), dir, opts, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "main", status.Branch)
+	assert.True(t, status.HasChanges)
+	assert.Equal(t, models.ChangeCounts{Modified: 1, Untracked: 1}, status.Changes)
+}
+
+func TestFallbackBackend_UsesFallbackOnError(t *testing.T) {
+	isolateUserConfig(t)
+	repoPath := createTestRepoWithState(t, "with-changes")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+
+	backend := fallbackBackend{primary: unavailableBackend{err: errGitNotFound}, fallback: goGitBackend{}}
+	wtStatus, err := backend.WorktreeStatus(context.Background(), repo, repoPath, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeCounts{Modified: 1}, countChanges(wtStatus))
+}
+
+func TestGoGitBackend_StopsWhenContextIsDone(t *testing.T) {
+	isolateUserConfig(t)
+	repoPath := createTestRepoWithState(t, "with-changes")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = goGitBackend{}.WorktreeStatus(ctx, repo, repoPath, nil)
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,84 @@
+package gitstatus
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// extractBranches audits every local branch other than the current one: how it
+// compares with its upstream, and whether it is merged into the default branch.
+// Branches are flagged as having no upstream only if the repository has remotes.
+func extractBranches(ctx context.Context, repo *git.Repository, status *models.GitStatus) error {
+	cfg, err := repo.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read repository config: %w", err)
+	}
+
+	var current plumbing.ReferenceName
+	if head, err := repo.Head(); err == nil {
+		current = head.Name()
+	}
+
+	var defaultTip plumbing.Hash
+	if status.DefaultBranch != "" {
+		if ref, err := repo.Reference(plumbing.NewBranchReferenceName(status.DefaultBranch), true); err == nil {
+			defaultTip = ref.Hash()
+		}
+	}
+
+	iter, err := repo.Branches()
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+	var refs []*plumbing.Reference
+	_ = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Name() != current {
+			refs = append(refs, ref)
+		}
+
+		return nil
+	})
+	slices.SortFunc(refs, func(a, b *plumbing.Reference) int {
+		return strings.Compare(a.Name().String(), b.Name().String())
+	})
+
+	index, closer := commitNodeIndex(repo)
+	if closer != nil {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+
+	status.Branches = make([]models.BranchStatus, 0, len(refs))
+	for _, ref := range refs {
+		branch := models.BranchStatus{Name: ref.Name().Short()}
+
+		upstream, err := compareWithUpstream(ctx, repo, cfg, index, branch.Name, ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to compare branch %s with its upstream: %w", branch.Name, err)
+		}
+		branch.Upstream = upstream.name
+		branch.Ahead, branch.Behind = upstream.ahead, upstream.behind
+		branch.NoUpstream = upstream.missing && status.HasRemote
+		branch.UpstreamGone = upstream.gone
+
+		// Merged: no commit on the branch is missing from the default branch
+		if !defaultTip.IsZero() && branch.Name != status.DefaultBranch {
+			unmerged, _, err := countAheadBehind(ctx, index, ref.Hash(), defaultTip)
+			if err != nil {
+				return fmt.Errorf("failed to compare branch %s with %s: %w", branch.Name, status.DefaultBranch, err)
+			}
+			branch.Merged = unmerged == 0
+		}
+
+		status.Branches = append(status.Branches, branch)
+	}
+
+	return nil
+}
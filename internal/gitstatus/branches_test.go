@@ -0,0 +1,92 @@
+package gitstatus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract_AuditsAllBranches(t *testing.T) {
+	isolateUserConfig(t)
+	repoPath := createTestRepoWithState(t, "with-remote")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	base := head.Hash()
+
+	// commitOn creates a branch at from with one more commit and returns that commit.
+	commitOn := func(branch string, from plumbing.Hash) plumbing.Hash {
+		require.NoError(t, worktree.Checkout(&git.CheckoutOptions{
+			Branch: plumbing.NewBranchReferenceName(branch), Hash: from, Create: true,
+		}))
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, branch+".txt"), []byte(branch), 0o600))
+		_, err := worktree.Add(branch + ".txt")
+		require.NoError(t, err)
+		sig := &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()}
+		hash, err := worktree.Commit("Work on "+branch, &git.CommitOptions{Author: sig})
+		require.NoError(t, err)
+
+		return hash
+	}
+	track := func(branch string, remoteTip plumbing.Hash) {
+		ref := plumbing.NewRemoteReferenceName("origin", branch)
+		if !remoteTip.IsZero() {
+			require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(ref, remoteTip)))
+		}
+		require.NoError(t, repo.CreateBranch(&config.Branch{
+			Name: branch, Remote: "origin", Merge: plumbing.NewBranchReferenceName(branch),
+		}))
+	}
+
+	pushed := commitOn("pushed", base)
+	track("pushed", pushed)
+	unpushed := commitOn("unpushed", base)
+	commitOn("unpushed2", unpushed) // Creates a branch one commit ahead of unpushed
+	track("unpushed2", unpushed)
+	commitOn("local", base)
+	gone := commitOn("gone", base)
+	track("gone", plumbing.ZeroHash)
+
+	// Back on the first branch, which also has gone's work merged
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: head.Name()}))
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(head.Name(), gone)))
+	require.NoError(t, worktree.Reset(&git.ResetOptions{Commit: gone, Mode: git.HardReset}))
+
+	opts := DefaultOptions()
+	opts.AllBranches = true
+	status, err := Extract(context.Background(), repoPath, opts, []gitignore.Pattern{})
+	require.NoError(t, err)
+	require.Equal(t, head.Name().Short(), status.DefaultBranch)
+
+	assert.Equal(t, []models.BranchStatus{
+		{Name: "gone", Upstream: "origin/gone", UpstreamGone: true, Merged: true},
+		{Name: "local", NoUpstream: true},
+		{Name: "pushed", Upstream: "origin/pushed"},
+		{Name: "unpushed", NoUpstream: true},
+		{Name: "unpushed2", Upstream: "origin/unpushed2", Ahead: 1},
+	}, status.Branches, "branches other than the current one, by name")
+	assert.False(t, status.IsStandardStatus(), "unpushed work on other branches needs attention")
+}
+
+func TestExtract_BranchesNotAuditedByDefault(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "with-ahead")
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.Nil(t, status.Branches)
+}
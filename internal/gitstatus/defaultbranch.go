@@ -0,0 +1,95 @@
+package gitstatus
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+// DefaultBranchNames returns the branch names tried, in order, when a repository's
+// default branch is neither recorded for a remote nor set by init.defaultBranch.
+func DefaultBranchNames() []string {
+	return []string{"main", "master"}
+}
+
+// loadUserConfig returns the user's git configuration (~/.gitconfig and
+// $XDG_CONFIG_HOME/git/config), or nil if it cannot be read.
+func loadUserConfig() *format.Config {
+	globalCfg, err := config.LoadConfig(config.GlobalScope)
+	if err != nil {
+		return nil
+	}
+
+	return globalCfg.Raw
+}
+
+// extractDefaultBranch resolves the branch the repository's work is based on:
+//  1. the branch refs/remotes/<remote>/HEAD points to, as set by "git clone" or
+//     "git remote set-head" (origin first, then the other remotes by name)
+//  2. init.defaultBranch, if that branch exists locally
+//  3. the first of candidates (DefaultBranchNames if empty) that exists locally
+//
+// The default branch is left empty if none of these resolve.
+func extractDefaultBranch(repo *git.Repository, status *models.GitStatus, candidates []string) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return
+	}
+
+	remotes := make([]string, 0, len(cfg.Remotes))
+	for name := range cfg.Remotes {
+		remotes = append(remotes, name)
+	}
+	slices.SortFunc(remotes, func(a, b string) int {
+		if (a == defaultRemote) != (b == defaultRemote) {
+			if a == defaultRemote {
+				return -1
+			}
+
+			return 1
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	for _, remote := range remotes {
+		ref, err := repo.Storer.Reference(plumbing.NewRemoteHEADReferenceName(remote))
+		if err != nil || ref.Type() != plumbing.SymbolicReference {
+			continue
+		}
+		prefix := "refs/remotes/" + remote + "/"
+		if branch, ok := strings.CutPrefix(ref.Target().String(), prefix); ok && branch != "" {
+			status.DefaultBranch = branch
+
+			return
+		}
+	}
+
+	hasBranch := func(name string) bool {
+		_, err := repo.Storer.Reference(plumbing.NewBranchReferenceName(name))
+
+		return err == nil
+	}
+
+	if name := configOption([]*format.Config{cfg.Raw, loadUserConfig()}, "init", "", "defaultBranch"); name != "" && hasBranch(name) {
+		status.DefaultBranch = name
+
+		return
+	}
+
+	if len(candidates) == 0 {
+		candidates = DefaultBranchNames()
+	}
+	for _, name := range candidates {
+		if hasBranch(name) {
+			status.DefaultBranch = name
+
+			return
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package gitstatus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// isolateUserConfig points the user's git configuration to an empty home directory
+// and returns the path of its .gitconfig.
+func isolateUserConfig(t *testing.T) string {
+	t.Helper()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+
+	return filepath.Join(home, ".gitconfig")
+}
+
+// createRepoWithBranches creates a repository whose first commit is on each of the given branches.
+func createRepoWithBranches(t *testing.T, branches ...string) *git.Repository {
+	t.Helper()
+
+	repo, err := git.PlainOpen(createTestRepoWithState(t, "basic"))
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	for _, branch := range branches {
+		ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), head.Hash())
+		require.NoError(t, repo.Storer.SetReference(ref))
+	}
+
+	return repo
+}
+
+// addRemoteHEAD adds a remote whose HEAD points to branch, as "git clone" records it.
+func addRemoteHEAD(t *testing.T, repo *git.Repository, remote, branch string) {
+	t.Helper()
+
+	_, err := repo.CreateRemote(&config.RemoteConfig{
+		Name:  remote,
+		URLs:  []string{"https://example.com/" + remote + ".git"},
+		Fetch: []config.RefSpec{config.RefSpec("+refs/heads/*:refs/remotes/" + remote + "/*")},
+	})
+	require.NoError(t, err)
+
+	target := plumbing.NewRemoteReferenceName(remote, branch)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.NewRemoteHEADReferenceName(remote), target)))
+}
+
+func TestExtractDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name       string
+		setup      func(t *testing.T, repo *git.Repository, userConfig string)
+		candidates []string
+		expected   string
+	}{
+		{
+			name: "remote HEAD",
+			setup: func(t *testing.T, repo *git.Repository, _ string) {
+				t.Helper()
+				addRemoteHEAD(t, repo, "origin", "develop")
+			},
+			expected: "develop",
+		},
+		{
+			name: "origin before other remotes",
+			setup: func(t *testing.T, repo *git.Repository, _ string) {
+				t.Helper()
+				addRemoteHEAD(t, repo, "fork", "trunk")
+				addRemoteHEAD(t, repo, "origin", "release")
+			},
+			expected: "release",
+		},
+		{
+			name: "other remote without origin",
+			setup: func(t *testing.T, repo *git.Repository, _ string) {
+				t.Helper()
+				addRemoteHEAD(t, repo, "upstream", "trunk")
+			},
+			expected: "trunk",
+		},
+		{
+			name: "init.defaultBranch in repository config",
+			setup: func(t *testing.T, repo *git.Repository, _ string) {
+				t.Helper()
+				cfg, err := repo.Config()
+				require.NoError(t, err)
+				cfg.Raw.Section("init").SetOption("defaultBranch", "trunk")
+				require.NoError(t, repo.SetConfig(cfg))
+			},
+			expected: "trunk",
+		},
+		{
+			name: "init.defaultBranch in user config",
+			setup: func(t *testing.T, _ *git.Repository, userConfig string) {
+				t.Helper()
+				require.NoError(t, os.WriteFile(userConfig, []byte("[init]\n\tdefaultBranch = develop\n"), 0o600))
+			},
+			expected: "develop",
+		},
+		{
+			name: "init.defaultBranch that does not exist",
+			setup: func(t *testing.T, _ *git.Repository, userConfig string) {
+				t.Helper()
+				require.NoError(t, os.WriteFile(userConfig, []byte("[init]\n\tdefaultBranch = missing\n"), 0o600))
+			},
+			candidates: []string{"release"},
+			expected:   "release",
+		},
+		{
+			name:       "first existing candidate",
+			candidates: []string{"missing", "trunk", "develop"},
+			expected:   "trunk",
+		},
+		{
+			name:       "no candidate exists",
+			candidates: []string{"missing"},
+			expected:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userConfig := isolateUserConfig(t)
+			repo := createRepoWithBranches(t, "develop", "trunk", "release")
+			if tt.setup != nil {
+				tt.setup(t, repo, userConfig)
+			}
+
+			status := &models.GitStatus{}
+			extractDefaultBranch(repo, status, tt.candidates)
+			assert.Equal(t, tt.expected, status.DefaultBranch)
+		})
+	}
+}
+
+func TestExtract_CleanOnDetectedDefaultBranch(t *testing.T) {
+	isolateUserConfig(t)
+	repoPath := createTestRepoWithState(t, "basic")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+
+	// A repository cloned from a remote whose default branch is develop, checked out on develop
+	head, err := repo.Head()
+	require.NoError(t, err)
+	develop := plumbing.NewBranchReferenceName("develop")
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(develop, head.Hash())))
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, develop)))
+	addRemoteHEAD(t, repo, "origin", "develop")
+	remoteDevelop := plumbing.NewRemoteReferenceName("origin", "develop")
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(remoteDevelop, head.Hash())))
+	require.NoError(t, repo.CreateBranch(&config.Branch{Name: "develop", Remote: "origin", Merge: develop}))
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "develop", status.DefaultBranch)
+	assert.True(t, status.IsOnDefaultBranch())
+	assert.True(t, status.IsStandardStatus(), "develop is the normal branch of this repository")
+}
@@ -0,0 +1,120 @@
+package gitstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+var errPorcelainFormat = errors.New("unexpected git status output")
+
+// gitWaitDelay bounds how long a git process killed on timeout is waited for: processes
+// it started, such as hooks, can keep its output open after it exits.
+const gitWaitDelay = 2 * time.Second
+
+// gitCLIBackend computes the working tree status by running git.
+type gitCLIBackend struct {
+	gitPath string
+}
+
+func (b gitCLIBackend) WorktreeStatus(
+	ctx context.Context, _ *git.Repository, worktreePath string, _ []gitignore.Pattern,
+) (git.Status, error) {
+	// Untracked files are listed one by one, as go-git does, rather than by directory.
+	// --no-optional-locks keeps git from refreshing the index, which would race with
+	// git commands the user runs meanwhile.
+	cmd := exec.CommandContext(ctx, b.gitPath, "-C", worktreePath, "--no-optional-locks",
+		"status", "--porcelain=v2", "-z", "--untracked-files=all", "--ignore-submodules=none")
+	cmd.Env = append(cmd.Environ(), "LC_ALL=C", "GIT_TERMINAL_PROMPT=0")
+	cmd.WaitDelay = gitWaitDelay
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git status: %w: %s", err, msg)
+		}
+
+		return nil, fmt.Errorf("git status: %w", err)
+	}
+
+	return parsePorcelainV2(out)
+}
+
+// parsePorcelainV2 parses the output of "git status --porcelain=v2 -z" into go-git's
+// representation. Header lines ("# ...") and ignored files ("! ...") are skipped.
+func parsePorcelainV2(out []byte) (git.Status, error) {
+	status := make(git.Status)
+
+	records := strings.Split(string(out), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if record == "" {
+			continue
+		}
+
+		switch record[0] {
+		case '#', '!':
+			continue
+		case '?':
+			path, ok := strings.CutPrefix(record, "? ")
+			if !ok {
+				return nil, fmt.Errorf("%w: %q", errPorcelainFormat, record)
+			}
+			status[path] = &git.FileStatus{Staging: git.Untracked, Worktree: git.Untracked}
+		case '1':
+			// 1 XY sub mH mI mW hH hI path
+			fields := strings.SplitN(record, " ", 9)
+			if len(fields) != 9 || len(fields[1]) != 2 {
+				return nil, fmt.Errorf("%w: %q", errPorcelainFormat, record)
+			}
+			status[fields[8]] = porcelainFileStatus(fields[1])
+		case '2':
+			// 2 XY sub mH mI mW hH hI Xscore path, then the original path as the next record
+			fields := strings.SplitN(record, " ", 10)
+			if len(fields) != 10 || len(fields[1]) != 2 || i+1 >= len(records) {
+				return nil, fmt.Errorf("%w: %q", errPorcelainFormat, record)
+			}
+			i++
+			fileStatus := porcelainFileStatus(fields[1])
+			fileStatus.Extra = records[i]
+			status[fields[9]] = fileStatus
+		case 'u':
+			// u XY sub m1 m2 m3 mW h1 h2 h3 path
+			fields := strings.SplitN(record, " ", 11)
+			if len(fields) != 11 {
+				return nil, fmt.Errorf("%w: %q", errPorcelainFormat, record)
+			}
+			status[fields[10]] = &git.FileStatus{Staging: git.UpdatedButUnmerged, Worktree: git.UpdatedButUnmerged}
+		default:
+			return nil, fmt.Errorf("%w: %q", errPorcelainFormat, record)
+		}
+	}
+
+	return status, nil
+}
+
+// porcelainFileStatus converts the XY field of a porcelain v2 entry, where "." means
+// unmodified and "T" a type change (e.g. a file replaced by a symlink).
+func porcelainFileStatus(xy string) *git.FileStatus {
+	code := func(c byte) git.StatusCode {
+		switch c {
+		case '.':
+			return git.Unmodified
+		case 'T':
+			return git.Modified
+		default:
+			return git.StatusCode(c)
+		}
+	}
+
+	return &git.FileStatus{Staging: code(xy[0]), Worktree: code(xy[1])}
+}
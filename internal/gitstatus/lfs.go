@@ -0,0 +1,350 @@
+package gitstatus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+const (
+	lfsPointerMaxSize = 1024 // Largest a Git LFS pointer file can be; larger files are never pointers
+	gitattributesFile = ".gitattributes"
+)
+
+// lfsPointer matches a Git LFS pointer file and captures the SHA-256 of the object it stands for.
+//
+//nolint:gochecknoglobals // Compiled once, read-only
+var lfsPointer = regexp.MustCompile(`\Aversion https://git-lfs\.github\.com/spec/v1\n(?:[a-z0-9.-]+ [^\n]*\n)*?oid sha256:([0-9a-f]{64})\n`)
+
+// extractLFS detects whether the repository uses Git LFS, from the filter=lfs
+// patterns of its top-level .gitattributes and info/attributes or the LFS
+// directory in the Git directory, and counts:
+//   - files checked out as pointers: their content was never downloaded, so the
+//     working tree looks clean while missing the real files
+//   - LFS objects stored locally for the LFS files changed by commits of the current
+//     branch that no remote-tracking branch contains, which only "git push" uploads
+func extractLFS(ctx context.Context, repo *git.Repository, status *models.GitStatus) error {
+	common, ok := commonDirFilesystem(repo)
+	if !ok {
+		return nil
+	}
+
+	worktree, err := repo.Worktree()
+	if err != nil {
+		worktree = nil // Bare repository: objects can still be unpushed
+	}
+
+	patterns, tracksLFS := readAttributes(common, worktree)
+	if !tracksLFS && !pathExists(common, "lfs") {
+		return nil
+	}
+	status.LFS = &models.LFSStatus{}
+
+	if worktree != nil && tracksLFS {
+		status.LFS.Unfetched, err = countUnfetchedPointers(ctx, repo, worktree.Filesystem, patterns)
+		if err != nil {
+			return err
+		}
+	}
+
+	// A branch even with its upstream has nothing to push; one without has no upstream to
+	// compare with, but can still be compared with what was pushed of other branches
+	if status.Ahead > 0 || status.NoUpstream || status.UpstreamGone {
+		status.LFS.Unpushed, err = countUnpushedObjects(ctx, repo, common)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commonDirFilesystem returns the Git directory shared by all worktrees of a repository,
+// where Git LFS stores its objects.
+func commonDirFilesystem(repo *git.Repository) (billy.Filesystem, bool) {
+	fs, ok := gitDirFilesystem(repo)
+	if !ok {
+		return nil, false
+	}
+
+	// A linked worktree's Git directory names the main one in its commondir file
+	data, err := readSmallFile(fs, "commondir")
+	if err != nil {
+		return fs, true
+	}
+	commonDir := strings.TrimSpace(string(data))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(fs.Root(), commonDir)
+	}
+
+	return osfs.New(commonDir), true
+}
+
+// readAttributes returns the patterns of the top-level .gitattributes and info/attributes,
+// in increasing order of precedence, and whether any of them sets filter=lfs.
+func readAttributes(gitDir billy.Filesystem, worktree *git.Worktree) ([]gitattributes.MatchAttribute, bool) {
+	var sources []io.Reader
+	if worktree != nil {
+		if data, err := readSmallFile(worktree.Filesystem, gitattributesFile); err == nil {
+			sources = append(sources, bytes.NewReader(data))
+		}
+	}
+	if data, err := readSmallFile(gitDir, path.Join("info", "attributes")); err == nil {
+		sources = append(sources, bytes.NewReader(data))
+	}
+
+	var (
+		patterns  []gitattributes.MatchAttribute
+		tracksLFS bool
+	)
+	for _, source := range sources {
+		attrs, err := gitattributes.ReadAttributes(source, nil, true)
+		if err != nil {
+			continue
+		}
+		patterns = append(patterns, attrs...)
+		for _, attr := range attrs {
+			for _, a := range attr.Attributes {
+				tracksLFS = tracksLFS || isLFSFilter(a)
+			}
+		}
+	}
+
+	return patterns, tracksLFS
+}
+
+// isLFSFilter reports whether an attribute is filter=lfs.
+func isLFSFilter(attr gitattributes.Attribute) bool {
+	return attr.Name() == "filter" && attr.IsValueSet() && attr.Value() == "lfs"
+}
+
+// readSmallFile reads a whole configuration or state file.
+func readSmallFile(fs billy.Filesystem, name string) ([]byte, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.ReadAll(f)
+}
+
+// countUnfetchedPointers counts the tracked LFS files whose working tree copy is a pointer.
+// Only files small enough to be pointers when they were last staged are read.
+func countUnfetchedPointers(
+	ctx context.Context, repo *git.Repository, fs billy.Filesystem, patterns []gitattributes.MatchAttribute,
+) (int, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return 0, nil //nolint:nilerr // No index yet, nothing is checked out
+	}
+
+	matcher := gitattributes.NewMatcher(patterns)
+	unfetched := 0
+	for _, entry := range idx.Entries {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		if entry.Size > lfsPointerMaxSize || entry.Stage != 0 || !isLFSPath(matcher, entry.Name) {
+			continue
+		}
+		if data, err := readPointerCandidate(fs, entry.Name); err == nil && lfsPointer.Match(data) {
+			unfetched++
+		}
+	}
+
+	return unfetched, nil
+}
+
+// isLFSPath reports whether a file is stored with Git LFS according to the matcher.
+func isLFSPath(matcher gitattributes.Matcher, name string) bool {
+	attrs, matched := matcher.Match(strings.Split(name, "/"), []string{"filter"})
+	if !matched {
+		return false
+	}
+	filter, ok := attrs["filter"]
+
+	return ok && isLFSFilter(filter)
+}
+
+// readPointerCandidate reads the start of a working tree file, enough to hold a pointer.
+func readPointerCandidate(fs billy.Filesystem, name string) ([]byte, error) {
+	f, err := fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	return io.ReadAll(io.LimitReader(f, lfsPointerMaxSize))
+}
+
+// countUnpushedObjects counts the LFS objects stored locally that the LFS files changed
+// by unpushed commits point to. Like the pre-push hook of Git LFS, commits reachable
+// from a remote-tracking branch count as pushed, so a branch without an upstream is
+// compared with everything fetched. Repositories without remotes have nowhere to push.
+func countUnpushedObjects(ctx context.Context, repo *git.Repository, common billy.Filesystem) (int, error) {
+	head, err := repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return 0, nil //nolint:nilerr // Nothing to push without a current branch
+	}
+	remotes, err := repo.Remotes()
+	if err != nil || len(remotes) == 0 {
+		return 0, err
+	}
+	pushed, err := remoteTrackingTips(repo)
+	if err != nil {
+		return 0, err
+	}
+
+	// The walk stops where the branch meets what was pushed, so the cost depends on
+	// how many commits are unpushed rather than on the length of the history
+	index, closer := commitNodeIndex(repo)
+	if closer != nil {
+		defer func() {
+			_ = closer.Close()
+		}()
+	}
+	walk, err := walkDivergence(ctx, index, head.Hash(), pushed...)
+	if err != nil {
+		return 0, err
+	}
+
+	oids := make(map[string]bool)
+	for hash, side := range walk.counted {
+		if side != sideLocal {
+			continue
+		}
+		added, err := addedBlobs(ctx, repo, hash)
+		if err != nil {
+			return 0, err
+		}
+		for _, blobHash := range added {
+			blob, err := repo.BlobObject(blobHash)
+			if err != nil || blob.Size > lfsPointerMaxSize {
+				continue
+			}
+			oid, ok := blobPointerOID(blob)
+			if ok && pathExists(common, path.Join("lfs", "objects", oid[0:2], oid[2:4], oid)) {
+				oids[oid] = true
+			}
+		}
+	}
+
+	return len(oids), nil
+}
+
+// remoteTrackingTips returns the commits the remote-tracking branches point to.
+func remoteTrackingTips(repo *git.Repository) ([]plumbing.Hash, error) {
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var tips []plumbing.Hash
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		// Symbolic references such as refs/remotes/origin/HEAD name another branch
+		if ref.Name().IsRemote() && ref.Type() == plumbing.HashReference {
+			tips = append(tips, ref.Hash())
+		}
+
+		return nil
+	})
+
+	return tips, err
+}
+
+// addedBlobs returns the blobs a commit adds or changes compared with each of its
+// parents: a merge only brings in what none of the merged branches had.
+func addedBlobs(ctx context.Context, repo *git.Repository, hash plumbing.Hash) ([]plumbing.Hash, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	parentTrees := []*object.Tree{{}} // A root commit adds every file
+	if commit.NumParents() > 0 {
+		parentTrees = parentTrees[:0]
+		err = commit.Parents().ForEach(func(parent *object.Commit) error {
+			parentTree, err := parent.Tree()
+			parentTrees = append(parentTrees, parentTree)
+
+			return err
+		})
+		if errors.Is(err, plumbing.ErrObjectNotFound) {
+			parentTrees = []*object.Tree{{}} // Shallow clone boundary
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	// Subtrees with the same hash on both sides are skipped, so the cost depends on
+	// how much changed rather than on the size of the tree
+	changedIn := make(map[plumbing.Hash]int)
+	for _, parentTree := range parentTrees {
+		changes, err := object.DiffTreeWithOptions(ctx, parentTree, tree, nil)
+		if err != nil {
+			return nil, err
+		}
+		changed := make(map[plumbing.Hash]bool, len(changes))
+		for _, change := range changes {
+			if change.To.Name != "" { // Not deleted
+				changed[change.To.TreeEntry.Hash] = true
+			}
+		}
+		for blobHash := range changed {
+			changedIn[blobHash]++
+		}
+	}
+
+	var added []plumbing.Hash
+	for blobHash, count := range changedIn {
+		if count == len(parentTrees) {
+			added = append(added, blobHash)
+		}
+	}
+
+	return added, nil
+}
+
+// blobPointerOID returns the object a blob points to if it is a Git LFS pointer.
+func blobPointerOID(blob *object.Blob) (string, bool) {
+	r, err := blob.Reader()
+	if err != nil {
+		return "", false
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(r, lfsPointerMaxSize))
+	if err != nil {
+		return "", false
+	}
+	match := lfsPointer.FindSubmatch(data)
+	if match == nil {
+		return "", false
+	}
+
+	return string(match[1]), true
+}
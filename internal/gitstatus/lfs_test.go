@@ -0,0 +1,252 @@
+package gitstatus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lfsPointerFor returns the pointer Git LFS commits in place of content, and the object ID.
+func lfsPointerFor(content string) (string, string) {
+	sum := sha256.Sum256([]byte(content))
+	oid := hex.EncodeToString(sum[:])
+
+	return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, len(content)), oid
+}
+
+// storeLFSObject adds an object to the local Git LFS store of a repository.
+func storeLFSObject(t *testing.T, dir, oid, content string) {
+	t.Helper()
+
+	writeFile(t, dir, filepath.Join(".git", "lfs", "objects", oid[0:2], oid[2:4], oid), content)
+}
+
+// extractLFSStatus runs the full status extraction of a repository and returns its LFS state.
+func extractLFSStatus(t *testing.T, dir string) *models.GitStatus {
+	t.Helper()
+
+	status, err := Extract(context.Background(), dir, &ExtractOptions{Backend: BackendGoGit}, nil)
+	require.NoError(t, err)
+
+	return status
+}
+
+func TestExtractLFS_NotUsed(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+
+	assert.Nil(t, extractLFSStatus(t, dir).LFS)
+}
+
+func TestExtractLFS_DetectedFromLFSDirectory(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+	_, oid := lfsPointerFor("data")
+	storeLFSObject(t, dir, oid, "data")
+
+	assert.Equal(t, &models.LFSStatus{}, extractLFSStatus(t, dir).LFS)
+
+	// Linked worktrees share the main repository's LFS store
+	worktree := filepath.Join(t.TempDir(), "wt")
+	runGit(t, dir, "worktree", "add", "--quiet", "-b", "wt", worktree)
+	assert.Equal(t, &models.LFSStatus{}, extractLFSStatus(t, worktree).LFS)
+}
+
+func TestExtractLFS_UnfetchedPointers(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+
+	// Without git-lfs installed the filter does nothing, so files are committed as they are
+	pointer, _ := lfsPointerFor("big model")
+	writeFile(t, dir, ".gitattributes", "*.bin filter=lfs diff=lfs merge=lfs -text\nplain.bin -filter\n")
+	writeFile(t, dir, "model.bin", pointer)
+	writeFile(t, dir, "assets/texture.bin", pointer)
+	writeFile(t, dir, "fetched.bin", "real content")
+	writeFile(t, dir, "plain.bin", pointer) // Not stored with LFS
+	writeFile(t, dir, "notes.txt", pointer) // Not stored with LFS
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add LFS files")
+
+	status := extractLFSStatus(t, dir)
+
+	require.NotNil(t, status.LFS)
+	assert.Equal(t, models.LFSStatus{Unfetched: 2}, *status.LFS)
+	assert.False(t, status.HasChanges, "git status shows pointer files as clean")
+}
+
+func TestExtractLFS_UnpushedObjects(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+	writeFile(t, dir, ".gitattributes", "*.bin filter=lfs\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Track binaries with LFS")
+
+	// The remote has everything up to here
+	runGit(t, dir, "remote", "add", "origin", "https://example.com/repo.git")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	runGit(t, dir, "branch", "--quiet", "--set-upstream-to=origin/main")
+
+	local, localOID := lfsPointerFor("new model")
+	storeLFSObject(t, dir, localOID, "new model")
+	missing, _ := lfsPointerFor("object only another clone has")
+	writeFile(t, dir, "model.bin", local)
+	writeFile(t, dir, "copy.bin", local)
+	writeFile(t, dir, "other.bin", missing)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add models")
+
+	status := extractLFSStatus(t, dir)
+
+	assert.Equal(t, 1, status.Ahead)
+	require.NotNil(t, status.LFS)
+	assert.Equal(t, 1, status.LFS.Unpushed, "objects are counted once, and only if stored locally")
+	assert.False(t, status.IsStandardStatus())
+
+	// Once pushed, nothing is left to upload
+	runGit(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	assert.Equal(t, 0, extractLFSStatus(t, dir).LFS.Unpushed)
+}
+
+func TestExtractLFS_UnpushedObjectsWhenAheadAndBehind(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+	base, baseOID := lfsPointerFor("model")
+	storeLFSObject(t, dir, baseOID, "model")
+	writeFile(t, dir, ".gitattributes", "*.bin filter=lfs\n")
+	writeFile(t, dir, "model.bin", base)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add model")
+	runGit(t, dir, "remote", "add", "origin", "https://example.com/repo.git")
+
+	// Someone else pushed a new version of the model
+	runGit(t, dir, "checkout", "--quiet", "-b", "upstream")
+	updated, _ := lfsPointerFor("model, retrained")
+	writeFile(t, dir, "model.bin", updated)
+	runGit(t, dir, "commit", "--quiet", "-am", "Retrain model")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	runGit(t, dir, "checkout", "--quiet", "main")
+	runGit(t, dir, "branch", "--quiet", "-D", "upstream")
+	runGit(t, dir, "branch", "--quiet", "--set-upstream-to=origin/main")
+
+	local, localOID := lfsPointerFor("local model")
+	storeLFSObject(t, dir, localOID, "local model")
+	writeFile(t, dir, "local.bin", local)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add local model")
+
+	status := extractLFSStatus(t, dir)
+
+	assert.Equal(t, 1, status.Ahead)
+	assert.Equal(t, 1, status.Behind)
+	require.NotNil(t, status.LFS)
+	assert.Equal(t, 1, status.LFS.Unpushed, "files changed only upstream are not counted")
+}
+
+func TestExtractLFS_UnpushedObjectsWithoutUpstream(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+	pushed, pushedOID := lfsPointerFor("pushed model")
+	storeLFSObject(t, dir, pushedOID, "pushed model")
+	writeFile(t, dir, ".gitattributes", "*.bin filter=lfs\n")
+	writeFile(t, dir, "pushed.bin", pushed)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add pushed model")
+
+	// Without a remote, there is nowhere to push to
+	runGit(t, dir, "checkout", "--quiet", "-b", "feature")
+	local, localOID := lfsPointerFor("local model")
+	storeLFSObject(t, dir, localOID, "local model")
+	writeFile(t, dir, "local.bin", local)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add local model")
+	assert.Equal(t, 0, extractLFSStatus(t, dir).LFS.Unpushed)
+
+	// Only main was pushed: the branch is compared with it
+	runGit(t, dir, "remote", "add", "origin", "https://example.com/repo.git")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/main", "main")
+	status := extractLFSStatus(t, dir)
+	assert.True(t, status.NoUpstream)
+	assert.Equal(t, 1, status.LFS.Unpushed)
+
+	// The upstream was deleted on the remote
+	runGit(t, dir, "config", "branch.feature.remote", "origin")
+	runGit(t, dir, "config", "branch.feature.merge", "refs/heads/feature")
+	status = extractLFSStatus(t, dir)
+	assert.True(t, status.UpstreamGone)
+	assert.Equal(t, 1, status.LFS.Unpushed)
+
+	// Objects of a version overwritten before pushing are uploaded too
+	newer, newerOID := lfsPointerFor("local model, retrained")
+	storeLFSObject(t, dir, newerOID, "local model, retrained")
+	writeFile(t, dir, "local.bin", newer)
+	runGit(t, dir, "commit", "--quiet", "-am", "Retrain local model")
+	assert.Equal(t, 2, extractLFSStatus(t, dir).LFS.Unpushed)
+}
+
+func TestExtractLFS_UnpushedObjectsOfMerges(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+	writeFile(t, dir, ".gitattributes", "*.bin filter=lfs\n")
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Track binaries with LFS")
+	runGit(t, dir, "remote", "add", "origin", "https://example.com/repo.git")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	runGit(t, dir, "branch", "--quiet", "--set-upstream-to=origin/main")
+
+	// Someone else pushed a model, fetched along with its LFS object
+	runGit(t, dir, "checkout", "--quiet", "-b", "upstream")
+	upstream, upstreamOID := lfsPointerFor("upstream model")
+	storeLFSObject(t, dir, upstreamOID, "upstream model")
+	writeFile(t, dir, "upstream.bin", upstream)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add upstream model")
+	runGit(t, dir, "update-ref", "refs/remotes/origin/main", "HEAD")
+	runGit(t, dir, "checkout", "--quiet", "main")
+	runGit(t, dir, "branch", "--quiet", "-D", "upstream")
+
+	local, localOID := lfsPointerFor("local model")
+	storeLFSObject(t, dir, localOID, "local model")
+	writeFile(t, dir, "local.bin", local)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add local model")
+	runGit(t, dir, "merge", "--quiet", "--no-edit", "origin/main")
+
+	status := extractLFSStatus(t, dir)
+
+	assert.Equal(t, 2, status.Ahead)
+	assert.Equal(t, 1, status.LFS.Unpushed, "the merge brings in nothing unpushed")
+}
+
+func TestExtractLFS_StopsWhenContextIsDone(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+	pointer, _ := lfsPointerFor("data")
+	writeFile(t, dir, ".gitattributes", "*.bin filter=lfs\n")
+	writeFile(t, dir, "data.bin", pointer)
+	runGit(t, dir, "add", ".")
+	runGit(t, dir, "commit", "--quiet", "-m", "Add data")
+
+	repo, err := git.PlainOpen(dir)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = extractLFS(ctx, repo, &models.GitStatus{})
+	assert.ErrorIs(t, err, context.Canceled)
+}
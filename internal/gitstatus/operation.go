@@ -0,0 +1,84 @@
+package gitstatus
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+)
+
+// gitDirFilesystem returns the Git directory of a repository opened from disk. For a
+// linked worktree, per-worktree files (HEAD, MERGE_HEAD, ...) resolve to the worktree's
+// own Git directory and shared ones (objects, refs, ...) to the main repository's.
+func gitDirFilesystem(repo *git.Repository) (billy.Filesystem, bool) {
+	fsStorer, ok := repo.Storer.(interface{ Filesystem() billy.Filesystem })
+	if !ok {
+		return nil, false
+	}
+
+	return fsStorer.Filesystem(), true
+}
+
+// extractOperation detects a merge, rebase, cherry-pick, revert, bisect or am left in
+// progress, from the state files git keeps in the Git directory until it is finished
+// or aborted. A rebase or am also records its current step and number of steps.
+func extractOperation(repo *git.Repository, status *models.GitStatus) {
+	fs, ok := gitDirFilesystem(repo)
+	if !ok {
+		return
+	}
+
+	switch {
+	case pathExists(fs, "rebase-merge"):
+		// Interactive and merge-based rebases (the default since Git 2.26)
+		status.Operation = models.OperationRebase
+		status.OperationStep = readCounter(fs, "rebase-merge/msgnum")
+		status.OperationTotal = readCounter(fs, "rebase-merge/end")
+	case pathExists(fs, "rebase-apply"):
+		// "git am" and patch-based rebases share the directory
+		status.Operation = models.OperationRebase
+		if pathExists(fs, "rebase-apply/applying") {
+			status.Operation = models.OperationAm
+		}
+		status.OperationStep = readCounter(fs, "rebase-apply/next")
+		status.OperationTotal = readCounter(fs, "rebase-apply/last")
+	case pathExists(fs, "MERGE_HEAD"):
+		status.Operation = models.OperationMerge
+	case pathExists(fs, "CHERRY_PICK_HEAD"):
+		status.Operation = models.OperationCherryPick
+	case pathExists(fs, "REVERT_HEAD"):
+		status.Operation = models.OperationRevert
+	case pathExists(fs, "BISECT_LOG"):
+		status.Operation = models.OperationBisect
+	}
+
+	// Steps are informational; drop them if the state files are inconsistent
+	if status.OperationStep > status.OperationTotal {
+		status.OperationStep, status.OperationTotal = 0, 0
+	}
+}
+
+// pathExists reports whether a file or directory exists in the Git directory.
+func pathExists(fs billy.Filesystem, path string) bool {
+	_, err := fs.Stat(path)
+
+	return err == nil
+}
+
+// readCounter reads a step number git stores as a decimal in a file, or 0 if unavailable.
+func readCounter(fs billy.Filesystem, path string) int {
+	data, err := util.ReadFile(fs, path)
+	if err != nil {
+		return 0
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || n < 0 {
+		return 0
+	}
+
+	return n
+}
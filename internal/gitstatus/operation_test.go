@@ -0,0 +1,138 @@
+package gitstatus
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract_DetectsOperationInProgress(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string // State files to create in .git (a trailing "/" creates a directory)
+		operation models.Operation
+		step      int
+		total     int
+	}{
+		{name: "none", files: nil},
+		{
+			name:      "merge",
+			files:     map[string]string{"MERGE_HEAD": "0123456789abcdef0123456789abcdef01234567\n"},
+			operation: models.OperationMerge,
+		},
+		{
+			name:      "interactive rebase",
+			files:     map[string]string{"rebase-merge/msgnum": "3\n", "rebase-merge/end": "7\n"},
+			operation: models.OperationRebase,
+			step:      3,
+			total:     7,
+		},
+		{
+			name:      "patch-based rebase",
+			files:     map[string]string{"rebase-apply/next": "2\n", "rebase-apply/last": "4\n"},
+			operation: models.OperationRebase,
+			step:      2,
+			total:     4,
+		},
+		{
+			name:      "am",
+			files:     map[string]string{"rebase-apply/applying": "", "rebase-apply/next": "1\n", "rebase-apply/last": "2\n"},
+			operation: models.OperationAm,
+			step:      1,
+			total:     2,
+		},
+		{
+			name:      "rebase without step files",
+			files:     map[string]string{"rebase-merge/": ""},
+			operation: models.OperationRebase,
+		},
+		{
+			name:      "rebase with inconsistent step files",
+			files:     map[string]string{"rebase-merge/msgnum": "9\n", "rebase-merge/end": "x\n"},
+			operation: models.OperationRebase,
+		},
+		{
+			name:      "cherry-pick",
+			files:     map[string]string{"CHERRY_PICK_HEAD": "0123456789abcdef0123456789abcdef01234567\n"},
+			operation: models.OperationCherryPick,
+		},
+		{
+			name:      "revert",
+			files:     map[string]string{"REVERT_HEAD": "0123456789abcdef0123456789abcdef01234567\n"},
+			operation: models.OperationRevert,
+		},
+		{
+			name:      "bisect",
+			files:     map[string]string{"BISECT_LOG": "git bisect start\n"},
+			operation: models.OperationBisect,
+		},
+		{
+			name: "rebase takes precedence over bisect",
+			files: map[string]string{
+				"BISECT_LOG": "git bisect start\n", "rebase-merge/msgnum": "1\n", "rebase-merge/end": "2\n",
+			},
+			operation: models.OperationRebase,
+			step:      1,
+			total:     2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := createTestRepoWithState(t, "basic")
+			gitDir := filepath.Join(repoPath, ".git")
+			for name, content := range tt.files {
+				path := filepath.Join(gitDir, name)
+				if name[len(name)-1] == '/' {
+					require.NoError(t, os.MkdirAll(path, 0o750))
+
+					continue
+				}
+				require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o750))
+				require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+			}
+
+			status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.operation, status.Operation)
+			assert.Equal(t, tt.step, status.OperationStep)
+			assert.Equal(t, tt.total, status.OperationTotal)
+			require.NoError(t, status.Validate())
+		})
+	}
+}
+
+func TestExtract_CountsConflictedFilesAsUnmerged(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+
+	// A merge stopped at a conflict: both sides of test.txt are in the index, none at stage 0
+	idx, err := repo.Storer.Index()
+	require.NoError(t, err)
+	require.Len(t, idx.Entries, 1)
+	ours := *idx.Entries[0]
+	ours.Stage = index.OurMode
+	theirs := ours
+	theirs.Stage = index.TheirMode
+	idx.Entries = []*index.Entry{&ours, &theirs}
+	require.NoError(t, repo.Storer.SetIndex(idx))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, ".git", "MERGE_HEAD"), []byte(ours.Hash.String()+"\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("<<<<<<< ours\n>>>>>>> theirs\n"), 0o600))
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.Equal(t, models.OperationMerge, status.Operation)
+	assert.True(t, status.HasChanges)
+	assert.Equal(t, models.ChangeCounts{Unmerged: 1}, status.Changes)
+}
@@ -0,0 +1,93 @@
+package gitstatus
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+const (
+	// stashRef is the ref pointing at the latest stash; older ones live only in its reflog.
+	stashRef plumbing.ReferenceName = "refs/stash"
+	// stashReflog is the reflog of stashRef, relative to the Git directory.
+	stashReflog = "logs/refs/stash"
+)
+
+// extractStashes counts the stashes of a repository and finds when the oldest one was made.
+// "git stash" keeps one reflog entry per stash (stash@{0} is the last line), and
+// "git stash drop" removes the entry, so the reflog lists exactly the existing stashes.
+func extractStashes(repo *git.Repository, status *models.GitStatus) {
+	ref, err := repo.Reference(stashRef, false)
+	if err != nil {
+		return
+	}
+	status.HasStashes = true
+
+	if fs, ok := gitDirFilesystem(repo); ok {
+		if times := readReflogTimes(fs, stashReflog); len(times) > 0 {
+			status.StashCount = len(times)
+			status.OldestStash = times[0]
+			for _, when := range times[1:] {
+				if when.Before(status.OldestStash) {
+					status.OldestStash = when
+				}
+			}
+
+			return
+		}
+	}
+
+	// Without a reflog (core.logAllRefUpdates=false) only the latest stash is known
+	status.StashCount = 1
+	if commit, err := repo.CommitObject(ref.Hash()); err == nil {
+		status.OldestStash = commit.Committer.When
+	}
+}
+
+// readReflogTimes returns the times of the entries of a reflog, oldest first, or nil
+// if it cannot be read. Malformed lines are skipped.
+func readReflogTimes(fs billy.Filesystem, path string) []time.Time {
+	data, err := util.ReadFile(fs, path)
+	if err != nil {
+		return nil
+	}
+
+	var times []time.Time
+	lines := bufio.NewScanner(bytes.NewReader(data))
+	for lines.Scan() {
+		if when, ok := parseReflogTime(lines.Text()); ok {
+			times = append(times, when)
+		}
+	}
+
+	return times
+}
+
+// parseReflogTime returns the time of a reflog entry, formatted as
+// "<old-hash> <new-hash> Name <email> <unix-seconds> <tz-offset>\t<message>".
+func parseReflogTime(line string) (time.Time, bool) {
+	header, _, _ := strings.Cut(line, "\t")
+	emailEnd := strings.LastIndexByte(header, '>')
+	if emailEnd < 0 {
+		return time.Time{}, false
+	}
+
+	fields := strings.Fields(header[emailEnd+1:])
+	if len(fields) == 0 {
+		return time.Time{}, false
+	}
+	seconds, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(seconds, 0), true
+}
@@ -0,0 +1,108 @@
+package gitstatus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReflogTime(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want time.Time
+		ok   bool
+	}{
+		{
+			name: "stash entry",
+			line: "0000000000000000000000000000000000000000 1111111111111111111111111111111111111111 " +
+				"Test User <test@example.com> 1700000000 +0100\tWIP on main: abc1234 Initial commit",
+			want: time.Unix(1700000000, 0),
+			ok:   true,
+		},
+		{
+			name: "email containing a space-separated name",
+			line: "0000000000000000000000000000000000000000 1111111111111111111111111111111111111111 " +
+				"A B C <a@b> 1600000000 -0700\tOn feature: message with <brackets> 42",
+			want: time.Unix(1600000000, 0),
+			ok:   true,
+		},
+		{name: "no identity", line: "garbage", ok: false},
+		{name: "no timestamp", line: "0 1 Test <t@e>\tmessage", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseReflogTime(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtract_CountsStashesFromReflog(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference("refs/stash", head.Hash())))
+
+	// Three stashes, the oldest made 40 days ago
+	now := time.Now()
+	var reflog strings.Builder
+	for i, age := range []time.Duration{40 * 24 * time.Hour, 3 * time.Hour, time.Minute} {
+		fmt.Fprintf(&reflog, "%s %s Test User <test@example.com> %d +0000\tWIP on main: stash %d\n",
+			plumbing.ZeroHash, head.Hash(), now.Add(-age).Unix(), i)
+	}
+	logPath := filepath.Join(repoPath, ".git", "logs", "refs", "stash")
+	require.NoError(t, os.MkdirAll(filepath.Dir(logPath), 0o750))
+	require.NoError(t, os.WriteFile(logPath, []byte(reflog.String()), 0o600))
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.True(t, status.HasStashes)
+	assert.Equal(t, 3, status.StashCount)
+	assert.Equal(t, now.Add(-40*24*time.Hour).Unix(), status.OldestStash.Unix())
+	assert.True(t, status.HasStashOlderThan(30*24*time.Hour, now))
+	assert.False(t, status.HasStashOlderThan(50*24*time.Hour, now))
+}
+
+func TestExtract_StashWithoutReflog(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "with-stash")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	commit, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.True(t, status.HasStashes)
+	assert.Equal(t, 1, status.StashCount)
+	assert.Equal(t, commit.Committer.When.Unix(), status.OldestStash.Unix())
+}
+
+func TestExtract_NoStashes(t *testing.T) {
+	status, err := Extract(context.Background(), createTestRepoWithState(t, "basic"), nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.False(t, status.HasStashes)
+	assert.Zero(t, status.StashCount)
+	assert.True(t, status.OldestStash.IsZero())
+}
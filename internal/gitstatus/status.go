@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -15,9 +16,10 @@ import (
 	"github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/osfs"
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
-	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/object/commitgraph"
 )
 
 // ExtractOptions configures the Git status extraction behavior.
@@ -25,11 +27,33 @@ type ExtractOptions struct {
 	// Timeout is the maximum time to spend extracting status for a single repository
 	Timeout time.Duration
 
+	// Retries is how many more times extraction is attempted for a repository that
+	// failed or timed out, each attempt with its own Timeout
+	Retries int
+
 	// MaxConcurrency limits the number of repositories processed concurrently in ExtractBatch
 	MaxConcurrency int
 
 	// Debug enables debug output for status extraction operations
 	Debug bool
+
+	// DefaultBranches are the branch names tried, in order, as the default branch of
+	// repositories whose remotes and configuration do not tell (nil = DefaultBranchNames())
+	DefaultBranches []string
+
+	// Backend computes the working tree status (empty = BackendGoGit)
+	Backend BackendName
+
+	// AllBranches audits every local branch, not only the current one (see GitStatus.Branches)
+	AllBranches bool
+
+	// StaleAfter flags repositories whose HEAD and local branches have not changed
+	// for longer than this as stale (0 = never)
+	StaleAfter time.Duration
+
+	// Progress, if set, is called after each repository has been processed in ExtractBatch
+	// or ExtractStream. It may be called from several goroutines at once.
+	Progress func(repoPath string)
 }
 
 const (
@@ -37,6 +61,8 @@ const (
 	defaultMaxConcurrency  = 10
 	maxFilesPerCategory    = 20
 	thresholdSlowOperation = 100 * time.Millisecond
+	shortHashLength        = 7 // Length of abbreviated commit hashes, as git shows them by default
+	retryDelay             = 100 * time.Millisecond
 )
 
 var (
@@ -58,12 +84,35 @@ func DefaultOptions() *ExtractOptions {
 	}
 }
 
-// Extract retrieves Git status information for a single repository.
+// Extract retrieves Git status information for a single repository. A repository
+// that fails or times out is tried again up to opts.Retries times, unless ctx is done.
 func Extract(ctx context.Context, repoPath string, opts *ExtractOptions, ignorePatterns []gitignore.Pattern) (*models.GitStatus, error) {
 	if opts == nil {
 		opts = DefaultOptions()
 	}
 
+	status, err := extractOnce(ctx, repoPath, opts, ignorePatterns)
+	for attempt := 1; err != nil && attempt <= opts.Retries && !errors.Is(err, git.ErrRepositoryNotExists); attempt++ {
+		// Back off a little, e.g. for a concurrent git command to release its lock
+		select {
+		case <-ctx.Done():
+			return status, err
+		case <-time.After(time.Duration(attempt) * retryDelay):
+		}
+
+		if opts.Debug {
+			debugPrintf("Retrying repository %s (attempt %d of %d): %v", repoPath, attempt, opts.Retries, err)
+		}
+		status, err = extractOnce(ctx, repoPath, opts, ignorePatterns)
+	}
+
+	return status, err
+}
+
+// extractOnce makes one attempt at extracting the status of a repository, within opts.Timeout.
+func extractOnce(
+	ctx context.Context, repoPath string, opts *ExtractOptions, ignorePatterns []gitignore.Pattern,
+) (*models.GitStatus, error) {
 	if opts.Debug {
 		debugPrintf("Starting status extraction for repository: %s", repoPath)
 	}
@@ -75,25 +124,16 @@ func Extract(ctx context.Context, repoPath string, opts *ExtractOptions, ignoreP
 		defer cancel()
 	}
 
-	// Channel to receive result
-	resultChan := make(chan *models.GitStatus, 1)
-	errorChan := make(chan error, 1)
-
-	go func() {
-		status, err := extractGitStatus(repoPath, opts, ignorePatterns)
-		if err != nil {
-			errorChan <- err
-
-			return
+	// Extraction checks ctx as it goes and stops soon after it is done. It is waited
+	// for even then, so that a timed-out repository stops using CPU and file handles
+	// before its concurrency slot is released or it is tried again
+	steps := &stepTracker{step: StepOpen}
+	status, err := extractGitStatus(ctx, repoPath, opts, ignorePatterns, steps)
+	if err != nil {
+		if ctx.Err() != nil {
+			return timedOut(ctx, repoPath, steps.current(), opts)
 		}
-		resultChan <- status
-	}()
 
-	// Wait for result or context cancellation
-	select {
-	case status := <-resultChan:
-		return status, nil
-	case err := <-errorChan:
 		// Return partial status with error
 		partialStatus := &models.GitStatus{
 			Branch: "N/A",
@@ -101,44 +141,127 @@ func Extract(ctx context.Context, repoPath string, opts *ExtractOptions, ignoreP
 		}
 
 		return partialStatus, err
-	case <-ctx.Done():
-		// Timeout or cancellation
-		partialStatus := &models.GitStatus{
-			Branch: "N/A",
-			Error:  "timeout",
-		}
+	}
+
+	return status, nil
+}
 
-		return partialStatus, ctx.Err()
+// timedOut returns the partial status and error of an extraction stopped during step.
+func timedOut(ctx context.Context, repoPath string, step ExtractStep, opts *ExtractOptions) (*models.GitStatus, error) {
+	if opts.Debug {
+		debugPrintf("Repository %s: %v during the %s step", repoPath, ctx.Err(), step)
 	}
+
+	partialStatus := &models.GitStatus{
+		Branch: "N/A",
+		Error:  "timeout",
+	}
+
+	return partialStatus, &StepError{Step: step, Err: ctx.Err()}
+}
+
+// ExtractStep is a stage of status extraction, reported for repositories that time out.
+type ExtractStep string
+
+const (
+	// StepOpen opens the repository.
+	StepOpen ExtractStep = "open"
+	// StepBranch reads the current and default branch, last commit, activity, operation in
+	// progress, remotes and stashes.
+	StepBranch ExtractStep = "branch"
+	// StepAheadBehind compares the current branch, and with AllBranches every local branch,
+	// with its upstream.
+	StepAheadBehind ExtractStep = "ahead/behind"
+	// StepWorktree computes the working tree status, including submodules and Git LFS files.
+	StepWorktree ExtractStep = "worktree"
+)
+
+// StepError is the error of an extraction stopped during Step, because it timed out or
+// its context was canceled.
+type StepError struct {
+	Step ExtractStep
+	Err  error // The context's error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("%v during the %s step", e.Err, e.Step)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// stepTracker records the step an extraction is in, to report where it timed out.
+type stepTracker struct {
+	step ExtractStep
+}
+
+// enter records that extraction moves on to step, and returns ctx's error if it is done.
+func (t *stepTracker) enter(ctx context.Context, step ExtractStep) error {
+	t.step = step
+
+	return ctx.Err()
+}
+
+func (t *stepTracker) current() ExtractStep {
+	return t.step
 }
 
 // extractGitStatus performs the actual Git status extraction.
-func extractGitStatus(repoPath string, opts *ExtractOptions, ignorePatterns []gitignore.Pattern) (*models.GitStatus, error) {
+func extractGitStatus(
+	ctx context.Context, repoPath string, opts *ExtractOptions, ignorePatterns []gitignore.Pattern, steps *stepTracker,
+) (*models.GitStatus, error) {
 	startTime := time.Now()
 
-	// Open repository
-	repo, err := git.PlainOpen(repoPath)
+	if err := steps.enter(ctx, StepOpen); err != nil {
+		return nil, err
+	}
+
+	// Open repository (commondir support resolves linked worktrees to their main repository)
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
 
 	status := &models.GitStatus{}
 
+	if err := steps.enter(ctx, StepBranch); err != nil {
+		return nil, err
+	}
+
 	// Extract branch name and detached HEAD status
 	if err := extractBranch(repo, status); err != nil {
 		status.Branch = "N/A"
 		status.Error = err.Error()
+	} else {
+		extractLastCommit(repo, status)
 	}
 
+	// Resolve the branch the repository's work is based on
+	extractDefaultBranch(repo, status, opts.DefaultBranches)
+
+	// Check when HEAD or a local branch last changed
+	extractLastActivity(repo, status, opts.StaleAfter, time.Now())
+
+	// Check for a merge, rebase, etc. left in progress
+	extractOperation(repo, status)
+
 	// Check for remote
 	if err := extractRemote(repo, status); err != nil {
 		// Non-fatal: just means no remote
 		status.HasRemote = false
 	}
 
+	// Check for stashes
+	extractStashes(repo, status)
+
+	if err := steps.enter(ctx, StepAheadBehind); err != nil {
+		return nil, err
+	}
+
 	// Extract ahead/behind counts if remote exists
 	if status.HasRemote {
-		if err := extractAheadBehind(repo, status); err != nil {
+		if err := extractAheadBehind(ctx, repo, status); err != nil {
 			// Non-fatal: log error but continue
 			if status.Error == "" {
 				status.Error = err.Error()
@@ -146,11 +269,19 @@ func extractGitStatus(repoPath string, opts *ExtractOptions, ignorePatterns []gi
 		}
 	}
 
-	// Check for stashes
-	status.HasStashes = extractStashes(repo)
+	// Audit the other local branches
+	if opts.AllBranches {
+		if err := extractBranches(ctx, repo, status); err != nil && status.Error == "" {
+			status.Error = err.Error()
+		}
+	}
+
+	if err := steps.enter(ctx, StepWorktree); err != nil {
+		return nil, err
+	}
 
 	// Check for uncommitted changes
-	if err := extractUncommittedChanges(repo, status, opts, ignorePatterns); err != nil {
+	if err := extractUncommittedChanges(ctx, repo, status, opts, ignorePatterns); err != nil {
 		// Non-fatal for bare repos
 		if !errors.Is(err, git.ErrIsBareRepository) {
 			if status.Error == "" {
@@ -159,6 +290,21 @@ func extractGitStatus(repoPath string, opts *ExtractOptions, ignorePatterns []gi
 		}
 	}
 
+	// Check that submodules are checked out as recorded
+	if err := extractSubmodules(ctx, repo, status, newBackend(opts.Backend), ignorePatterns); err != nil && status.Error == "" {
+		status.Error = err.Error()
+	}
+
+	// Check for Git LFS content missing from the working tree or the remote
+	if err := extractLFS(ctx, repo, status); err != nil && status.Error == "" {
+		status.Error = err.Error()
+	}
+
+	// A step stopped midway by ctx leaves the status incomplete
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
 	// Debug output
 	if opts.Debug {
 		printDebugSummary(repoPath, status, startTime)
@@ -177,9 +323,27 @@ func printDebugSummary(repoPath string, status *models.GitStatus, startTime time
 
 	// Status summary
 	statusParts := []string{"branch=" + status.Branch}
+	if status.DefaultBranch != "" {
+		statusParts = append(statusParts, "defaultBranch="+status.DefaultBranch)
+	}
 	statusParts = append(statusParts, fmt.Sprintf("hasChanges=%t", status.HasChanges))
+	if status.Operation != "" {
+		statusParts = append(statusParts, "operation="+status.OperationLabel())
+	}
 	if status.HasRemote {
 		statusParts = append(statusParts, "hasRemote=true")
+		if status.Upstream != "" {
+			statusParts = append(statusParts, "upstream="+status.Upstream)
+		}
+		if status.PushTarget != "" && status.PushTarget != status.Upstream {
+			statusParts = append(statusParts, "push="+status.PushTarget)
+		}
+		if status.NoUpstream {
+			statusParts = append(statusParts, "noUpstream=true")
+		}
+		if status.UpstreamGone {
+			statusParts = append(statusParts, "upstreamGone=true")
+		}
 		if status.Ahead > 0 {
 			statusParts = append(statusParts, fmt.Sprintf("ahead=%d", status.Ahead))
 		}
@@ -189,8 +353,29 @@ func printDebugSummary(repoPath string, status *models.GitStatus, startTime time
 	} else {
 		statusParts = append(statusParts, "hasRemote=false")
 	}
+	if status.LastCommit != nil {
+		statusParts = append(statusParts, "lastCommit="+status.LastCommit.Hash)
+	}
+	if !status.LastActivity.IsZero() {
+		statusParts = append(statusParts, "lastActivity="+status.LastActivity.Format(time.RFC3339))
+	}
+	if status.IsStale {
+		statusParts = append(statusParts, "stale=true")
+	}
+	if status.Branches != nil {
+		statusParts = append(statusParts, fmt.Sprintf("branches=%d", len(status.Branches)))
+	}
+	if status.Submodules != nil {
+		statusParts = append(statusParts, fmt.Sprintf("submodules=%d", len(status.Submodules)))
+	}
+	if status.LFS != nil {
+		statusParts = append(statusParts, fmt.Sprintf("lfsUnfetched=%d, lfsUnpushed=%d", status.LFS.Unfetched, status.LFS.Unpushed))
+	}
 	if status.HasStashes {
-		statusParts = append(statusParts, "hasStashes=true")
+		statusParts = append(statusParts, fmt.Sprintf("stashes=%d", status.StashCount))
+		if !status.OldestStash.IsZero() {
+			statusParts = append(statusParts, "oldestStash="+status.OldestStash.Format(time.RFC3339))
+		}
 	}
 
 	debugPrintf("Repository %s: %s", repoPath, strings.Join(statusParts, ", "))
@@ -214,6 +399,26 @@ func extractBranch(repo *git.Repository, status *models.GitStatus) error {
 	return nil
 }
 
+// extractLastCommit records the commit HEAD points to. A branch without commits has none.
+func extractLastCommit(repo *git.Repository, status *models.GitStatus) {
+	head, err := repo.Head()
+	if err != nil {
+		return
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return
+	}
+
+	subject, _, _ := strings.Cut(strings.TrimSpace(commit.Message), "\n")
+	status.LastCommit = &models.CommitInfo{
+		Hash:    head.Hash().String()[:shortHashLength],
+		Author:  commit.Author.Name,
+		Date:    commit.Committer.When,
+		Subject: strings.TrimSpace(subject),
+	}
+}
+
 // extractRemote checks if the repository has a remote configured.
 func extractRemote(repo *git.Repository, status *models.GitStatus) error {
 	remotes, err := repo.Remotes()
@@ -232,104 +437,91 @@ func extractRemote(repo *git.Repository, status *models.GitStatus) error {
 	return errNoRemotes
 }
 
-// extractAheadBehind calculates commits ahead and behind the remote tracking branch.
-func extractAheadBehind(repo *git.Repository, status *models.GitStatus) error {
+// extractAheadBehind resolves the upstream and push target of the current branch
+// and calculates commits ahead and behind the upstream.
+func extractAheadBehind(ctx context.Context, repo *git.Repository, status *models.GitStatus) error {
 	// Get local HEAD
 	head, err := repo.Head()
 	if err != nil {
 		return err
 	}
-
-	// Get remote tracking branch
-	branchName := head.Name().Short()
-	remoteBranchRefName := plumbing.NewRemoteReferenceName("origin", branchName)
-
-	remoteRef, err := repo.Reference(remoteBranchRefName, false)
-	if err != nil {
-		// No remote tracking branch
-		status.Ahead = 0
-		status.Behind = 0
-
-		return err
+	if !head.Name().IsBranch() {
+		return nil // Detached HEAD has no upstream
 	}
 
-	// Count commits between local and remote
-	localCommit, err := repo.CommitObject(head.Hash())
+	cfg, err := repo.Config()
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to read repository config: %w", err)
 	}
 
-	remoteCommit, err := repo.CommitObject(remoteRef.Hash())
-	if err != nil {
-		return err
+	// push.default and remote.pushDefault are usually set in the user's configuration
+	branchName := head.Name().Short()
+	if pushRef, ok := resolvePushTarget(cfg, loadUserConfig(), branchName); ok {
+		status.PushTarget = pushRef.Short()
 	}
 
-	// Count ahead (commits in local not in remote)
-	ahead, err := countCommitsBetween(repo, localCommit, remoteCommit)
-	if err != nil {
-		return err
+	// Count commits between local and upstream, reading the commit-graph if there is one
+	index, closer := commitNodeIndex(repo)
+	if closer != nil {
+		defer func() {
+			_ = closer.Close()
+		}()
 	}
-	status.Ahead = ahead
 
-	// Count behind (commits in remote not in local)
-	behind, err := countCommitsBetween(repo, remoteCommit, localCommit)
-	if err != nil {
-		return err
-	}
-	status.Behind = behind
+	upstream, err := compareWithUpstream(ctx, repo, cfg, index, branchName, head.Hash())
+	status.Upstream = upstream.name
+	status.NoUpstream = upstream.missing
+	status.UpstreamGone = upstream.gone
+	status.Ahead, status.Behind = upstream.ahead, upstream.behind
 
-	return nil
+	return err
 }
 
-// countCommitsBetween counts commits from 'from' that are not in 'to'.
-func countCommitsBetween(repo *git.Repository, from, to *object.Commit) (int, error) {
-	// Get all commits reachable from 'to'
-	toCommits := make(map[plumbing.Hash]bool)
-	iter, err := repo.Log(&git.LogOptions{From: to.Hash})
-	if err != nil {
-		return 0, err
-	}
-	defer iter.Close()
-
-	err = iter.ForEach(func(c *object.Commit) error {
-		toCommits[c.Hash] = true
+// upstreamComparison is how a local branch compares with its upstream.
+type upstreamComparison struct {
+	name    string // Upstream branch, e.g. "origin/main" ("" if none is configured)
+	missing bool   // No upstream is configured (local-only branch)
+	gone    bool   // The upstream is configured but no longer exists
+	ahead   int
+	behind  int
+}
 
-		return nil
-	})
-	if err != nil {
-		return 0, fmt.Errorf("failed to iterate over commits: %w", err)
-	}
+// compareWithUpstream resolves the upstream of a local branch whose tip is at tip and
+// counts the commits ahead and behind it.
+func compareWithUpstream(
+	ctx context.Context, repo *git.Repository, cfg *config.Config, index commitgraph.CommitNodeIndex, branch string, tip plumbing.Hash,
+) (upstreamComparison, error) {
+	var result upstreamComparison
 
-	// Count commits reachable from 'from' that are not in 'to'
-	count := 0
-	iter, err = repo.Log(&git.LogOptions{From: from.Hash})
-	if err != nil {
-		return 0, err
-	}
-	defer iter.Close()
+	// Get remote tracking branch
+	upstreamRefName, ok := resolveUpstream(cfg, branch)
+	if !ok {
+		branchCfg, configured := cfg.Branches[branch]
+		if !configured || branchCfg.Remote == "" || branchCfg.Merge == "" {
+			result.missing = true // Local-only branch
 
-	err = iter.ForEach(func(c *object.Commit) error {
-		if !toCommits[c.Hash] {
-			count++
+			return result, nil
 		}
 
-		return nil
-	})
-	if err != nil {
-		return 0, err
-	}
+		// Configured, but its remote (or a matching fetch refspec) has been removed
+		result.name = branchCfg.Remote + "/" + branchCfg.Merge.Short()
+		result.gone = true
 
-	return count, nil
-}
+		return result, nil
+	}
+	result.name = upstreamRefName.Short()
 
-// extractStashes checks if the repository has any stashed changes.
-func extractStashes(repo *git.Repository) bool {
-	stashRef, err := repo.Reference("refs/stash", false)
+	upstreamRef, err := repo.Reference(upstreamRefName, true)
 	if err != nil {
-		return false
+		// Deleted on the remote and pruned locally (or never fetched)
+		result.gone = true
+
+		return result, nil
 	}
 
-	return stashRef != nil
+	result.ahead, result.behind, err = countAheadBehind(ctx, index, tip, upstreamRef.Hash())
+
+	return result, err
 }
 
 // readGitignoreFile reads a gitignore file directly and returns patterns.
@@ -368,42 +560,111 @@ func readGitignoreFile(path string) ([]gitignore.Pattern, error) {
 	return patterns, nil
 }
 
+// changeCategory is a kind of uncommitted change counted in models.ChangeCounts.
+type changeCategory int
+
+const (
+	changeStaged changeCategory = iota
+	changeModified
+	changeDeleted
+	changeRenamed
+	changeUntracked
+	changeUnmerged
+	changeCategoryCount
+)
+
+//nolint:gochecknoglobals // Read-only lookup table
+var changeCategoryNames = [changeCategoryCount]string{"Staged", "Modified", "Deleted", "Renamed", "Untracked", "Unmerged"}
+
+// categorizeChange returns the categories a file's status counts towards. Unmerged
+// and untracked files count once; otherwise the index and working tree columns
+// are counted separately, so a file staged and modified again is both.
+func categorizeChange(fileStatus *git.FileStatus) []changeCategory {
+	switch {
+	case fileStatus.Staging == git.UpdatedButUnmerged || fileStatus.Worktree == git.UpdatedButUnmerged:
+		return []changeCategory{changeUnmerged}
+	case fileStatus.Staging == git.Untracked || fileStatus.Worktree == git.Untracked:
+		return []changeCategory{changeUntracked}
+	}
+
+	var categories []changeCategory
+	switch fileStatus.Staging {
+	case git.Unmodified:
+	case git.Renamed:
+		categories = append(categories, changeRenamed)
+	default:
+		categories = append(categories, changeStaged)
+	}
+	switch fileStatus.Worktree {
+	case git.Unmodified:
+	case git.Deleted:
+		categories = append(categories, changeDeleted)
+	default:
+		categories = append(categories, changeModified)
+	}
+
+	return categories
+}
+
+// markUnmerged marks files with unresolved conflicts in a worktree status. go-git
+// does not report them: a conflicted file has several entries in the index, one per
+// side of the merge, none of them at stage 0.
+func markUnmerged(repo *git.Repository, wtStatus git.Status) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return
+	}
+
+	for _, entry := range idx.Entries {
+		// Stage 0 is a merged entry (go-git's index.Merged constant is 1, which is the ancestor stage)
+		if entry.Stage != 0 {
+			wtStatus[entry.Name] = &git.FileStatus{Staging: git.UpdatedButUnmerged, Worktree: git.UpdatedButUnmerged}
+		}
+	}
+}
+
+// countChanges counts the files of a worktree status by change category.
+func countChanges(wtStatus git.Status) models.ChangeCounts {
+	var counts [changeCategoryCount]int
+	for _, fileStatus := range wtStatus {
+		for _, category := range categorizeChange(fileStatus) {
+			counts[category]++
+		}
+	}
+
+	return models.ChangeCounts{
+		Staged:    counts[changeStaged],
+		Modified:  counts[changeModified],
+		Deleted:   counts[changeDeleted],
+		Renamed:   counts[changeRenamed],
+		Untracked: counts[changeUntracked],
+		Unmerged:  counts[changeUnmerged],
+	}
+}
+
 // categorizeAndPrintFiles categorizes and prints files with truncation.
 func categorizeAndPrintFiles(wtStatus git.Status) {
-	modifiedFiles := []string{}
-	untrackedFiles := []string{}
-	stagedFiles := []string{}
-	deletedFiles := []string{}
-
+	var files [changeCategoryCount][]string
 	for filename, fileStatus := range wtStatus {
-		switch {
-		case fileStatus.Worktree == git.Modified && fileStatus.Staging == git.Unmodified:
-			modifiedFiles = append(modifiedFiles, filename)
-		case fileStatus.Staging == git.Untracked && fileStatus.Worktree == git.Untracked:
-			untrackedFiles = append(untrackedFiles, filename)
-		case fileStatus.Staging != git.Unmodified && fileStatus.Staging != git.Untracked:
-			stagedFiles = append(stagedFiles, filename)
-		case fileStatus.Worktree == git.Deleted:
-			deletedFiles = append(deletedFiles, filename)
+		for _, category := range categorizeChange(fileStatus) {
+			files[category] = append(files[category], filename)
 		}
 	}
 
-	printFileList := func(category string, files []string) {
-		if len(files) == 0 {
-			return
+	for category, names := range files {
+		if len(names) == 0 {
+			continue
 		}
-		if len(files) <= maxFilesPerCategory {
-			debugPrintf("%s files (%d): %s", category, len(files), strings.Join(files, ", "))
+		slices.Sort(names)
+
+		name := changeCategoryNames[category]
+		if len(names) <= maxFilesPerCategory {
+			debugPrintf("%s files (%d): %s", name, len(names), strings.Join(names, ", "))
 		} else {
-			debugPrintf("%s files (%d): %s", category, len(files), strings.Join(files[:maxFilesPerCategory], ", "))
-			debugPrintf("...and %d more %s files", len(files)-maxFilesPerCategory, strings.ToLower(category))
+			debugPrintf("%s files (%d): %s", name, len(names), strings.Join(names[:maxFilesPerCategory], ", "))
+			debugPrintf("...and %d more %s files", len(names)-maxFilesPerCategory, strings.ToLower(name))
 		}
 	}
-
-	printFileList("Modified", modifiedFiles)
-	printFileList("Untracked", untrackedFiles)
-	printFileList("Staged", stagedFiles)
-	printFileList("Deleted", deletedFiles)
 }
 
 // loadGlobalIgnorePatterns loads global gitignore patterns from core.excludesfile and default locations.
@@ -496,6 +757,7 @@ func loadDefaultGlobalIgnore(xdgConfigHome string, opts *ExtractOptions) ([]giti
 
 // extractUncommittedChanges checks for uncommitted changes in the working tree.
 func extractUncommittedChanges(
+	ctx context.Context,
 	repo *git.Repository,
 	status *models.GitStatus,
 	opts *ExtractOptions,
@@ -512,20 +774,13 @@ func extractUncommittedChanges(
 		return fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	// Add pre-loaded global gitignore patterns to align with native git behavior
-	if len(ignorePatterns) > 0 {
-		worktree.Excludes = append(worktree.Excludes, ignorePatterns...)
-		if opts.Debug {
-			debugPrintf("Total excludes after adding global patterns: %d", len(worktree.Excludes))
-		}
-	}
-
-	wtStatus, err := worktree.Status()
+	wtStatus, err := newBackend(opts.Backend).WorktreeStatus(ctx, repo, worktree.Filesystem.Root(), ignorePatterns)
 	if err != nil {
 		return fmt.Errorf("failed to get worktree status: %w", err)
 	}
 
 	status.HasChanges = !wtStatus.IsClean()
+	status.Changes = countChanges(wtStatus)
 
 	if opts.Debug && status.HasChanges {
 		categorizeAndPrintFiles(wtStatus)
@@ -534,18 +789,80 @@ func extractUncommittedChanges(
 	return nil
 }
 
+// RepositoryError is the error status extraction failed with for one repository.
+type RepositoryError struct {
+	Path string // Repository path
+	Err  error  // Why extraction failed: context.DeadlineExceeded if it timed out
+}
+
+func (e *RepositoryError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *RepositoryError) Unwrap() error {
+	return e.Err
+}
+
+// IsTimeout reports whether extraction was stopped by its timeout or the caller's deadline.
+func (e *RepositoryError) IsTimeout() bool {
+	return errors.Is(e.Err, context.DeadlineExceeded)
+}
+
+// RepositoryErrors returns the per-repository errors in an error returned by
+// ExtractBatch or ExtractStream, by repository path.
+func RepositoryErrors(err error) map[string]*RepositoryError {
+	errs := make(map[string]*RepositoryError)
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		var repoErr *RepositoryError
+		if errors.As(err, &repoErr) {
+			errs[repoErr.Path] = repoErr
+		}
+
+		return errs
+	}
+	for _, e := range joined.Unwrap() {
+		var repoErr *RepositoryError
+		if errors.As(e, &repoErr) {
+			errs[repoErr.Path] = repoErr
+		}
+	}
+
+	return errs
+}
+
 // ExtractBatch extracts Git status for multiple repositories concurrently.
+// Repositories that fail still get a partial status; the returned error joins
+// a *RepositoryError for each of them (see RepositoryErrors).
 func ExtractBatch(
 	ctx context.Context, repos map[string]*models.Repository, opts *ExtractOptions) (map[string]*models.GitStatus, error,
 ) {
-	if opts == nil {
-		opts = DefaultOptions()
-	}
-
 	if len(repos) == 0 {
 		return make(map[string]*models.GitStatus), nil
 	}
 
+	queue := make(chan *models.Repository, len(repos))
+	for _, repo := range repos {
+		queue <- repo
+	}
+	close(queue)
+
+	return ExtractStream(ctx, queue, opts)
+}
+
+// ExtractStream extracts Git status concurrently for repositories received on
+// repos, starting on each one as soon as it arrives, e.g. from scanner.ScanStream.
+// It returns once repos is closed and every extraction has finished. Paths
+// received more than once are extracted once. Errors are returned as for ExtractBatch;
+// repositories skipped because ctx was done fail with ctx's error.
+func ExtractStream(
+	ctx context.Context, repos <-chan *models.Repository, opts *ExtractOptions) (map[string]*models.GitStatus, error,
+) {
+	if opts == nil {
+		opts = DefaultOptions()
+	}
+
 	// Load global gitignore patterns once for all repositories
 	osFS := osfs.New("/")
 	ignorePatterns, err := loadGlobalIgnorePatterns(osFS, opts)
@@ -556,6 +873,9 @@ func ExtractBatch(
 	if ignorePatterns == nil {
 		ignorePatterns = []gitignore.Pattern{}
 	}
+	if opts.Debug {
+		debugPrintf("Working tree status backend: %s (%d global exclude patterns)", backendLabel(opts.Backend), len(ignorePatterns))
+	}
 
 	// Create channels
 	type result struct {
@@ -564,56 +884,78 @@ func ExtractBatch(
 		err    error
 	}
 
-	results := make(chan result, len(repos))
+	results := make(chan result)
 	semaphore := make(chan struct{}, opts.MaxConcurrency)
 
 	var wg sync.WaitGroup
 
-	// Launch workers for each repository
-	for path := range repos {
-		wg.Add(1)
-
-		go func(repoPath string) {
-			defer wg.Done()
-
-			// Check context before starting
-			select {
-			case <-ctx.Done():
-				return
-			default:
-			}
-
-			// Acquire semaphore
-			select {
-			case semaphore <- struct{}{}:
-				defer func() { <-semaphore }()
-			case <-ctx.Done():
-				return
-			}
-
-			// Extract status
-			status, err := Extract(ctx, repoPath, opts, ignorePatterns)
-			results <- result{
-				path:   repoPath,
-				status: status,
-				err:    err,
+	// Launch a worker for each repository as it arrives
+	go func() {
+		seen := make(map[string]bool)
+		for repo := range repos {
+			if seen[repo.Path] {
+				continue
 			}
-		}(path)
-	}
+			seen[repo.Path] = true
+
+			wg.Add(1)
+
+			go func(repoPath string) {
+				defer wg.Done()
+
+				// Check context before starting
+				select {
+				case <-ctx.Done():
+					results <- result{path: repoPath, err: ctx.Err()}
+
+					return
+				default:
+				}
+
+				// Acquire semaphore
+				select {
+				case semaphore <- struct{}{}:
+					defer func() { <-semaphore }()
+				case <-ctx.Done():
+					results <- result{path: repoPath, err: ctx.Err()}
+
+					return
+				}
+
+				// Extract status
+				status, err := Extract(ctx, repoPath, opts, ignorePatterns)
+				if opts.Progress != nil {
+					opts.Progress(repoPath)
+				}
+				results <- result{
+					path:   repoPath,
+					status: status,
+					err:    err,
+				}
+			}(repo.Path)
+		}
 
-	// Wait for all workers to finish
-	go func() {
+		// Wait for all workers to finish
 		wg.Wait()
 		close(results)
 	}()
 
 	// Collect results
 	statuses := make(map[string]*models.GitStatus)
+	var errs []error
 	for r := range results {
 		if r.status != nil {
 			statuses[r.path] = r.status
 		}
+		if r.err != nil {
+			errs = append(errs, &RepositoryError{Path: r.path, Err: r.err})
+		}
 	}
 
-	return statuses, nil
+	// Deterministic order for the joined error message
+	slices.SortFunc(errs, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+
+	return statuses, errors.Join(errs...)
 }
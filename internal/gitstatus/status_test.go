@@ -2,9 +2,12 @@ package gitstatus
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sync"
 	"testing"
 	"time"
 
@@ -116,6 +119,14 @@ func createTestRepoWithState(t *testing.T, state string) string {
 		err = repo.Storer.SetReference(remoteRef)
 		require.NoError(t, err)
 
+		// Track it as "git push -u" would
+		err = repo.CreateBranch(&config.Branch{
+			Name:   branchName,
+			Remote: "origin",
+			Merge:  plumbing.NewBranchReferenceName(branchName),
+		})
+		require.NoError(t, err)
+
 	case "bare": //nolint:goconst // for clarity
 		// Close current repo and create bare repo
 		tempDirBare := t.TempDir()
@@ -196,6 +207,99 @@ func TestExtract_CalculatesAheadBehindCounts(t *testing.T) {
 	assert.Equal(t, 1, status.Ahead, "should be 1 commit ahead")
 	assert.Equal(t, 0, status.Behind, "should be 0 commits behind")
 	assert.True(t, status.HasRemote)
+	assert.Equal(t, "origin/"+status.Branch, status.Upstream)
+}
+
+// Test Extract() telling no remote, no upstream and a gone upstream apart.
+func TestExtract_UpstreamStates(t *testing.T) {
+	status, err := Extract(context.Background(), createTestRepoWithState(t, "basic"), nil, []gitignore.Pattern{})
+	require.NoError(t, err)
+	assert.False(t, status.HasRemote)
+	assert.False(t, status.NoUpstream, "Without remotes there is nothing to track")
+
+	// Remote exists, branch never pushed
+	repoPath := createTestRepoWithState(t, "with-remote")
+	status, err = Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+	require.NoError(t, err)
+	assert.True(t, status.HasRemote)
+	assert.True(t, status.NoUpstream)
+	assert.False(t, status.UpstreamGone)
+	assert.Empty(t, status.Error)
+
+	// Upstream configured, but its tracking ref is gone
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	require.NoError(t, repo.CreateBranch(&config.Branch{
+		Name:   status.Branch,
+		Remote: "origin",
+		Merge:  plumbing.NewBranchReferenceName(status.Branch),
+	}))
+	status, err = Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+	require.NoError(t, err)
+	assert.False(t, status.NoUpstream)
+	assert.True(t, status.UpstreamGone)
+	assert.Equal(t, "origin/"+status.Branch, status.Upstream)
+	assert.Empty(t, status.Error)
+
+	// Upstream remote removed altogether
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "backup", URLs: []string{"https://example.com/backup.git"}})
+	require.NoError(t, err)
+	require.NoError(t, repo.DeleteRemote("origin"))
+	status, err = Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+	require.NoError(t, err)
+	assert.True(t, status.HasRemote)
+	assert.True(t, status.UpstreamGone)
+	assert.Equal(t, "origin/"+status.Branch, status.Upstream)
+}
+
+// Test Extract() comparing against the configured upstream rather than origin/<branch>.
+func TestExtract_UsesConfiguredUpstream(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	// A fork: fetch from "upstream" (trunk), push to "fork"
+	for _, name := range []string{"upstream", "fork"} {
+		_, err = repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{"https://example.com/" + name + ".git"}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repo.CreateBranch(&config.Branch{
+		Name:   head.Name().Short(),
+		Remote: "upstream",
+		Merge:  plumbing.NewBranchReferenceName("trunk"),
+	}))
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.Raw.SetOption("branch", head.Name().Short(), "pushRemote", "fork")
+	require.NoError(t, repo.SetConfig(cfg))
+
+	// The upstream has one commit the local branch lacks; a same-named origin-style ref must be ignored
+	parent, err := repo.CommitObject(head.Hash())
+	require.NoError(t, err)
+	upstreamCommit := &object.Commit{
+		Author:       parent.Author,
+		Committer:    parent.Committer,
+		Message:      "Upstream commit",
+		TreeHash:     parent.TreeHash,
+		ParentHashes: []plumbing.Hash{parent.Hash},
+	}
+	obj := repo.Storer.NewEncodedObject()
+	require.NoError(t, upstreamCommit.Encode(obj))
+	upstreamHash, err := repo.Storer.SetEncodedObject(obj)
+	require.NoError(t, err)
+	require.NoError(t, repo.Storer.SetReference(
+		plumbing.NewHashReference("refs/remotes/upstream/trunk", upstreamHash)))
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.Equal(t, "upstream/trunk", status.Upstream)
+	assert.Equal(t, "fork/"+status.Branch, status.PushTarget)
+	assert.Equal(t, 0, status.Ahead)
+	assert.Equal(t, 1, status.Behind)
+	assert.Empty(t, status.Error)
 }
 
 // T036: Test Extract() detecting no remote.
@@ -236,6 +340,74 @@ func TestExtract_DetectsUncommittedChanges(t *testing.T) {
 	assert.True(t, status.HasChanges)
 }
 
+func TestExtract_CountsChangesByCategory(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	// Track two more files so one can be modified and one deleted
+	for _, name := range []string{"modified.txt", "deleted.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte("content"), 0o600))
+		_, err = worktree.Add(name)
+		require.NoError(t, err)
+	}
+	_, err = worktree.Commit("Add files", &git.CommitOptions{
+		Author: &object.Signature{Name: "Test User", Email: "test@example.com", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	// Staged: a new file and a staged modification that is then modified again
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "staged.txt"), []byte("new"), 0o600))
+	_, err = worktree.Add("staged.txt")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("staged"), 0o600))
+	_, err = worktree.Add("test.txt")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "test.txt"), []byte("staged, then modified"), 0o600))
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoPath, "modified.txt"), []byte("changed"), 0o600))
+	require.NoError(t, os.Remove(filepath.Join(repoPath, "deleted.txt")))
+	for _, name := range []string{"untracked1.txt", "untracked2.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(repoPath, name), []byte("untracked"), 0o600))
+	}
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	assert.True(t, status.HasChanges)
+	assert.Equal(t, models.ChangeCounts{Staged: 2, Modified: 2, Deleted: 1, Untracked: 2}, status.Changes)
+	require.NoError(t, status.Validate())
+}
+
+func TestCategorizeChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   git.FileStatus
+		expected []changeCategory
+	}{
+		{"untracked", git.FileStatus{Staging: git.Untracked, Worktree: git.Untracked}, []changeCategory{changeUntracked}},
+		{"added", git.FileStatus{Staging: git.Added, Worktree: git.Unmodified}, []changeCategory{changeStaged}},
+		{"staged deletion", git.FileStatus{Staging: git.Deleted, Worktree: git.Unmodified}, []changeCategory{changeStaged}},
+		{"renamed", git.FileStatus{Staging: git.Renamed, Worktree: git.Unmodified}, []changeCategory{changeRenamed}},
+		{"modified", git.FileStatus{Staging: git.Unmodified, Worktree: git.Modified}, []changeCategory{changeModified}},
+		{"deleted", git.FileStatus{Staging: git.Unmodified, Worktree: git.Deleted}, []changeCategory{changeDeleted}},
+		{
+			"staged and modified", git.FileStatus{Staging: git.Modified, Worktree: git.Modified},
+			[]changeCategory{changeStaged, changeModified},
+		},
+		{"unmerged", git.FileStatus{Staging: git.UpdatedButUnmerged, Worktree: git.Modified}, []changeCategory{changeUnmerged}},
+		{"unchanged", git.FileStatus{Staging: git.Unmodified, Worktree: git.Unmodified}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, categorizeChange(&tt.status))
+		})
+	}
+}
+
 // T039: Test Extract() handling bare repositories.
 func TestExtract_HandlesBareRepositories(t *testing.T) {
 	repoPath := createTestRepoWithState(t, "bare")
@@ -359,6 +531,76 @@ func TestExtract_NonExistentPath(t *testing.T) {
 	}
 }
 
+func TestExtract_ReportsTimeoutStep(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	status, err := Extract(ctx, repoPath, nil, []gitignore.Pattern{})
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	var stepErr *StepError
+	require.ErrorAs(t, err, &stepErr)
+	assert.Equal(t, StepOpen, stepErr.Step)
+	assert.Equal(t, "context deadline exceeded during the open step", err.Error())
+	assert.Equal(t, "timeout", status.Error)
+}
+
+func TestExtractGitStatus_StopsBetweenSteps(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	steps := &stepTracker{}
+	status, err := extractGitStatus(ctx, repoPath, DefaultOptions(), nil, steps)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, status)
+	assert.Equal(t, StepOpen, steps.current(), "extraction does not go past the first step")
+}
+
+func TestExtract_WaitsForTimedOutExtraction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Fake git is a shell script")
+	}
+	repoPath := createTestRepoWithState(t, "basic")
+
+	// A git whose child process outlives it, keeping its output open until it finishes
+	finished := filepath.Join(t.TempDir(), "finished")
+	fakeGit := filepath.Join(t.TempDir(), "git")
+	script := fmt.Sprintf("#!/bin/sh\n(sleep 0.5; touch %q) &\nwait\n", finished)
+	require.NoError(t, os.WriteFile(fakeGit, []byte(script), 0o700)) //nolint:gosec // Executable test script
+	defer func(lookPath func() (string, error)) { lookPathGit = lookPath }(lookPathGit)
+	lookPathGit = func() (string, error) { return fakeGit, nil }
+
+	opts := &ExtractOptions{Backend: BackendGit, Timeout: 100 * time.Millisecond}
+	_, err := Extract(context.Background(), repoPath, opts, nil)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.FileExists(t, finished, "extraction still running when Extract returned")
+}
+
+func TestExtract_RetriesFailedAttempts(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	opts := &ExtractOptions{Timeout: time.Nanosecond, Retries: 2}
+
+	start := time.Now()
+	_, err := Extract(context.Background(), repoPath, opts, []gitignore.Pattern{})
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.GreaterOrEqual(t, time.Since(start), 3*retryDelay, "two retries back off 1x and 2x retryDelay")
+}
+
+func TestExtract_DoesNotRetryMissingRepository(t *testing.T) {
+	opts := &ExtractOptions{Retries: 100}
+
+	start := time.Now()
+	_, err := Extract(context.Background(), filepath.Join(t.TempDir(), "missing"), opts, []gitignore.Pattern{})
+
+	require.ErrorIs(t, err, git.ErrRepositoryNotExists)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
 // Test ExtractBatch with empty repos map.
 func TestExtractBatch_EmptyRepos(t *testing.T) {
 	ctx := context.Background()
@@ -395,3 +637,144 @@ func TestExtractBatch_RespectsContextCancellation(t *testing.T) {
 	_ = err
 	// The key is that it shouldn't hang
 }
+
+// Test ExtractStream() processing repositories as they arrive, once per path.
+func TestExtractStream_ProcessesEachPathOnce(t *testing.T) {
+	repoPaths := []string{createTestRepoWithState(t, "basic"), createTestRepoWithState(t, "basic")}
+
+	var mu sync.Mutex
+	var processed []string
+	opts := &ExtractOptions{
+		Timeout:        10 * time.Second,
+		MaxConcurrency: 2,
+		Progress: func(repoPath string) {
+			mu.Lock()
+			defer mu.Unlock()
+			processed = append(processed, repoPath)
+		},
+	}
+
+	repos := make(chan *models.Repository)
+	go func() {
+		defer close(repos)
+		for _, repoPath := range append(repoPaths, repoPaths[0]) { // Duplicate path is skipped
+			repos <- &models.Repository{Path: repoPath, Name: filepath.Base(repoPath)}
+		}
+	}()
+
+	statuses, err := ExtractStream(context.Background(), repos, opts)
+
+	require.NoError(t, err)
+	assert.Len(t, statuses, 2)
+	for _, repoPath := range repoPaths {
+		assert.Contains(t, statuses, repoPath)
+	}
+	assert.ElementsMatch(t, repoPaths, processed, "Progress should be reported once per repository")
+}
+
+// Test Extract() opening a linked worktree whose .git is a gitdir pointer file.
+func TestExtract_LinkedWorktree(t *testing.T) {
+	mainPath := createTestRepoWithState(t, "basic")
+
+	repo, err := git.PlainOpen(mainPath)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+	err = repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName("feature"), head.Hash()))
+	require.NoError(t, err)
+
+	// Lay out the worktree the same way "git worktree add" does
+	worktreePath := t.TempDir()
+	worktreeGitDir := filepath.Join(mainPath, ".git", "worktrees", "feature")
+	require.NoError(t, os.MkdirAll(worktreeGitDir, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeGitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeGitDir, "gitdir"), []byte(worktreePath+"/.git\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreePath, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0o600))
+
+	ctx := context.Background()
+	status, err := Extract(ctx, worktreePath, nil, []gitignore.Pattern{})
+
+	require.NoError(t, err)
+	require.NotNil(t, status)
+	assert.Equal(t, "feature", status.Branch)
+	assert.False(t, status.IsDetached)
+}
+
+func TestExtract_RecordsLastCommit(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+
+	status, err := Extract(context.Background(), repoPath, nil, []gitignore.Pattern{})
+	require.NoError(t, err)
+	require.NotNil(t, status.LastCommit)
+
+	repo, err := git.PlainOpen(repoPath)
+	require.NoError(t, err)
+	head, err := repo.Head()
+	require.NoError(t, err)
+
+	assert.Equal(t, head.Hash().String()[:7], status.LastCommit.Hash)
+	assert.Equal(t, "Test User", status.LastCommit.Author)
+	assert.Equal(t, "Initial commit", status.LastCommit.Subject)
+	assert.WithinDuration(t, time.Now(), status.LastCommit.Date, time.Minute)
+}
+
+func TestExtract_NoLastCommitWithoutCommits(t *testing.T) {
+	tempDir := t.TempDir()
+	_, err := git.PlainInit(tempDir, false)
+	require.NoError(t, err)
+
+	status, err := Extract(context.Background(), tempDir, nil, []gitignore.Pattern{})
+	require.NoError(t, err)
+	assert.Nil(t, status.LastCommit)
+}
+
+func TestExtractBatch_ReturnsRepositoryErrors(t *testing.T) {
+	validPath := createTestRepoWithState(t, "basic")
+	missingPath := filepath.Join(t.TempDir(), "missing")
+	repos := map[string]*models.Repository{
+		validPath:   {Path: validPath, Name: "valid"},
+		missingPath: {Path: missingPath, Name: "missing"},
+	}
+
+	statuses, err := ExtractBatch(context.Background(), repos, nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), missingPath)
+	require.Len(t, statuses, 2, "failed repositories still get a partial status")
+	assert.Equal(t, "N/A", statuses[missingPath].Branch)
+
+	repoErrs := RepositoryErrors(err)
+	require.Len(t, repoErrs, 1)
+	require.Contains(t, repoErrs, missingPath)
+	assert.False(t, repoErrs[missingPath].IsTimeout())
+	assert.Contains(t, repoErrs[missingPath].Error(), "failed to open repository")
+}
+
+func TestExtractStream_ReportsRepositoriesSkippedAtDeadline(t *testing.T) {
+	repoPath := createTestRepoWithState(t, "basic")
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	queue := make(chan *models.Repository, 1)
+	queue <- &models.Repository{Path: repoPath, Name: "repo"}
+	close(queue)
+
+	statuses, err := ExtractStream(ctx, queue, nil)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, statuses)
+	repoErrs := RepositoryErrors(err)
+	require.Contains(t, repoErrs, repoPath)
+	assert.True(t, repoErrs[repoPath].IsTimeout())
+}
+
+func TestRepositoryErrors(t *testing.T) {
+	assert.Empty(t, RepositoryErrors(nil))
+
+	single := &RepositoryError{Path: "/a", Err: git.ErrRepositoryNotExists}
+	assert.Equal(t, map[string]*RepositoryError{"/a": single}, RepositoryErrors(single))
+
+	other := &RepositoryError{Path: "/b", Err: context.DeadlineExceeded}
+	assert.Equal(t, map[string]*RepositoryError{"/a": single, "/b": other}, RepositoryErrors(errors.Join(single, other)))
+}
@@ -0,0 +1,107 @@
+package gitstatus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+const gitmodulesFile = ".gitmodules"
+
+// extractSubmodules compares the submodules declared in .gitmodules with the commits
+// the superproject records for them in its index: whether each is checked out, at
+// that commit, and without uncommitted changes. Submodules are opened read-only;
+// unlike go-git's Submodule.Repository, nothing is initialized.
+func extractSubmodules(
+	ctx context.Context, repo *git.Repository, status *models.GitStatus, backend Backend, ignorePatterns []gitignore.Pattern,
+) error {
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return nil //nolint:nilerr // Bare repositories have no submodules checked out
+	}
+
+	data, err := readSmallFile(worktree.Filesystem, gitmodulesFile)
+	if err != nil {
+		return nil //nolint:nilerr // No submodules
+	}
+	modules := config.NewModules()
+	if err := modules.Unmarshal(data); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", gitmodulesFile, err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return fmt.Errorf("failed to read index: %w", err)
+	}
+
+	root := worktree.Filesystem.Root()
+	for _, module := range modules.Submodules {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// A submodule removed from the index but left in .gitmodules is not one anymore
+		entry, err := idx.Entry(module.Path)
+		if err != nil || entry.Mode != filemode.Submodule {
+			continue
+		}
+
+		sub := models.SubmoduleStatus{
+			Path:     module.Path,
+			Recorded: entry.Hash.String()[:shortHashLength],
+		}
+		path := filepath.Join(root, filepath.FromSlash(module.Path))
+		if err := extractSubmodule(ctx, path, entry.Hash, &sub, backend, ignorePatterns); err != nil {
+			return fmt.Errorf("failed to read submodule %s: %w", module.Path, err)
+		}
+		status.Submodules = append(status.Submodules, sub)
+	}
+	slices.SortFunc(status.Submodules, func(a, b models.SubmoduleStatus) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return nil
+}
+
+// extractSubmodule reads the commit checked out in the submodule at path, compares it with
+// the recorded one, and checks the submodule for uncommitted changes.
+func extractSubmodule(
+	ctx context.Context, path string, recorded plumbing.Hash, sub *models.SubmoduleStatus,
+	backend Backend, ignorePatterns []gitignore.Pattern,
+) error {
+	// An uninitialized submodule is an empty directory, or missing altogether
+	subRepo, err := git.PlainOpen(path)
+	if errors.Is(err, git.ErrRepositoryNotExists) {
+		sub.Uninitialized = true
+
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	head, err := subRepo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	sub.Current = head.Hash().String()[:shortHashLength]
+	sub.OutOfSync = head.Hash() != recorded
+
+	wtStatus, err := backend.WorktreeStatus(ctx, subRepo, path, ignorePatterns)
+	if err != nil {
+		return fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	sub.Modified = !wtStatus.IsClean()
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package gitstatus
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSuperproject creates a repository with a submodule at each of paths, each cloned
+// from its own repository, and returns the superproject's path.
+func newSuperproject(t *testing.T, paths ...string) string {
+	t.Helper()
+
+	dir := newFixtureRepo(t)
+	for _, path := range paths {
+		source := newFixtureRepo(t)
+		runGit(t, dir, "-c", "protocol.file.allow=always", "submodule", "--quiet", "add", source, path)
+	}
+	runGit(t, dir, "commit", "--quiet", "-m", "Add submodules")
+
+	return dir
+}
+
+func TestExtractSubmodules(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newSuperproject(t, "libs/clean", "libs/moved", "libs/dirty", "vendor/absent")
+
+	runGit(t, filepath.Join(dir, "libs", "moved"), "commit", "--quiet", "--allow-empty", "-m", "Not recorded")
+	writeFile(t, filepath.Join(dir, "libs", "dirty"), "README.md", "changed\n")
+	runGit(t, dir, "submodule", "--quiet", "deinit", "vendor/absent")
+
+	status, err := Extract(context.Background(), dir, &ExtractOptions{Backend: BackendGoGit}, nil)
+	require.NoError(t, err)
+
+	recorded := func(path string) string {
+		line := runGit(t, dir, "ls-files", "--stage", path) // "160000 <hash> 0\t<path>"
+
+		return strings.Fields(line)[1][:shortHashLength]
+	}
+	head := func(path string) string {
+		return strings.TrimSpace(runGit(t, filepath.Join(dir, path), "rev-parse", "--short=7", "HEAD"))
+	}
+	assert.Equal(t, []models.SubmoduleStatus{
+		{Path: "libs/clean", Recorded: recorded("libs/clean"), Current: recorded("libs/clean")},
+		{Path: "libs/dirty", Recorded: recorded("libs/dirty"), Current: recorded("libs/dirty"), Modified: true},
+		{Path: "libs/moved", Recorded: recorded("libs/moved"), Current: head("libs/moved"), OutOfSync: true},
+		{Path: "vendor/absent", Recorded: recorded("vendor/absent"), Uninitialized: true},
+	}, status.Submodules)
+	assert.False(t, status.IsStandardStatus())
+}
+
+func TestExtractSubmodules_WithGitBackend(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newSuperproject(t, "lib")
+	writeFile(t, filepath.Join(dir, "lib"), "new.txt", "untracked\n")
+
+	status, err := Extract(context.Background(), dir, &ExtractOptions{Backend: BackendGit}, nil)
+	require.NoError(t, err)
+
+	require.Len(t, status.Submodules, 1)
+	assert.True(t, status.Submodules[0].Modified)
+	assert.False(t, status.Submodules[0].OutOfSync)
+}
+
+func TestExtractSubmodules_IgnoresEntriesNotInIndex(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newSuperproject(t, "lib")
+
+	// Removed from the index, but still declared in .gitmodules
+	runGit(t, dir, "rm", "--quiet", "--cached", "lib")
+
+	status, err := Extract(context.Background(), dir, &ExtractOptions{Backend: BackendGoGit}, nil)
+	require.NoError(t, err)
+
+	assert.Nil(t, status.Submodules)
+}
+
+func TestExtractSubmodules_NoSubmodules(t *testing.T) {
+	requireGit(t)
+	isolateUserConfig(t)
+	dir := newFixtureRepo(t)
+
+	status, err := Extract(context.Background(), dir, &ExtractOptions{Backend: BackendGoGit}, nil)
+	require.NoError(t, err)
+
+	assert.Nil(t, status.Submodules)
+	assert.Empty(t, status.Error)
+}
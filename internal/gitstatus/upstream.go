@@ -0,0 +1,118 @@
+package gitstatus
+
+import (
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+const (
+	// localRemote is the branch.<name>.remote value of branches tracking another local branch.
+	localRemote = "."
+	// defaultRemote is the remote git falls back to when none is configured for a branch.
+	defaultRemote = "origin"
+)
+
+// configOption returns the value of an option from the first configuration that sets it.
+// Configurations are given from the most to the least specific (repository, then user).
+func configOption(configs []*format.Config, section, subsection, key string) string {
+	for _, raw := range configs {
+		if raw == nil || !raw.HasSection(section) {
+			continue
+		}
+
+		s := raw.Section(section)
+		if subsection == "" {
+			if s.HasOption(key) {
+				return s.Option(key)
+			}
+
+			continue
+		}
+		if s.HasSubsection(subsection) && s.Subsection(subsection).HasOption(key) {
+			return s.Subsection(subsection).Option(key)
+		}
+	}
+
+	return ""
+}
+
+// trackingRef maps a branch on a remote to the local ref that tracks it, following the
+// remote's fetch refspecs (refs/heads/main on origin is usually refs/remotes/origin/main).
+func trackingRef(cfg *config.Config, remote string, branch plumbing.ReferenceName) (plumbing.ReferenceName, bool) {
+	if remote == localRemote {
+		return branch, true
+	}
+
+	remoteCfg, ok := cfg.Remotes[remote]
+	if !ok {
+		return "", false
+	}
+
+	for _, spec := range remoteCfg.Fetch {
+		if spec.Match(branch) {
+			return spec.Dst(branch), true
+		}
+	}
+
+	return "", false
+}
+
+// resolveUpstream returns the ref a branch is compared against, as configured by
+// branch.<name>.remote and branch.<name>.merge, or false if it has no upstream.
+func resolveUpstream(cfg *config.Config, branch string) (plumbing.ReferenceName, bool) {
+	branchCfg, ok := cfg.Branches[branch]
+	if !ok || branchCfg.Remote == "" || branchCfg.Merge == "" {
+		return "", false
+	}
+
+	return trackingRef(cfg, branchCfg.Remote, branchCfg.Merge)
+}
+
+// resolvePushTarget returns the ref tracking the remote branch "git push" would update,
+// or false if pushing the branch without arguments would fail. The remote comes from
+// branch.<name>.pushRemote, remote.pushDefault or the upstream remote; the remote
+// branch is chosen by push.default. userCfg holds options set outside the repository.
+func resolvePushTarget(cfg *config.Config, userCfg *format.Config, branch string) (plumbing.ReferenceName, bool) {
+	configs := []*format.Config{cfg.Raw, userCfg}
+	localRef := plumbing.NewBranchReferenceName(branch)
+
+	fetchRemote := defaultRemote
+	var merge plumbing.ReferenceName
+	if branchCfg, ok := cfg.Branches[branch]; ok && branchCfg.Remote != "" {
+		fetchRemote = branchCfg.Remote
+		merge = branchCfg.Merge
+	}
+
+	pushRemote := configOption(configs, "branch", branch, "pushRemote")
+	if pushRemote == "" {
+		pushRemote = configOption(configs, "remote", "", "pushDefault")
+	}
+	if pushRemote == "" {
+		pushRemote = fetchRemote
+	}
+
+	switch configOption(configs, "push", "", "default") {
+	case "nothing":
+		return "", false
+	case "current", "matching":
+		return trackingRef(cfg, pushRemote, localRef)
+	case "upstream", "tracking":
+		if pushRemote != fetchRemote || merge == "" {
+			return "", false
+		}
+
+		return trackingRef(cfg, pushRemote, merge)
+	default: // "simple"
+		// Pushing to another remote than the upstream's (triangular workflow) works like "current"
+		if pushRemote != fetchRemote {
+			return trackingRef(cfg, pushRemote, localRef)
+		}
+		// Otherwise the upstream branch must have the same name
+		if merge != localRef {
+			return "", false
+		}
+
+		return trackingRef(cfg, pushRemote, merge)
+	}
+}
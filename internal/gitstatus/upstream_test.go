@@ -0,0 +1,128 @@
+package gitstatus
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-git/go-git/v5/config"
+	format "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseConfig reads a repository configuration from git config syntax.
+func parseConfig(t *testing.T, text string) *config.Config {
+	t.Helper()
+
+	cfg, err := config.ReadConfig(strings.NewReader(text))
+	require.NoError(t, err)
+
+	return cfg
+}
+
+const remotesConfig = `
+[remote "origin"]
+	url = https://example.com/origin.git
+	fetch = +refs/heads/*:refs/remotes/origin/*
+[remote "fork"]
+	url = https://example.com/fork.git
+	fetch = +refs/heads/*:refs/remotes/fork/*
+`
+
+// Test resolveUpstream() following branch.<name>.remote/merge and fetch refspecs.
+func TestResolveUpstream(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{name: "no branch config", config: remotesConfig, want: ""},
+		{
+			name:   "same name",
+			config: remotesConfig + "[branch \"feature\"]\n\tremote = origin\n\tmerge = refs/heads/feature\n",
+			want:   "refs/remotes/origin/feature",
+		},
+		{
+			name:   "different remote branch",
+			config: remotesConfig + "[branch \"feature\"]\n\tremote = fork\n\tmerge = refs/heads/main\n",
+			want:   "refs/remotes/fork/main",
+		},
+		{
+			name:   "local branch",
+			config: remotesConfig + "[branch \"feature\"]\n\tremote = .\n\tmerge = refs/heads/main\n",
+			want:   "refs/heads/main",
+		},
+		{
+			name:   "unknown remote",
+			config: remotesConfig + "[branch \"feature\"]\n\tremote = gone\n\tmerge = refs/heads/main\n",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, ok := resolveUpstream(parseConfig(t, tt.config), "feature")
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, ref.String())
+		})
+	}
+}
+
+// Test resolvePushTarget() applying pushRemote, remote.pushDefault and push.default.
+func TestResolvePushTarget(t *testing.T) {
+	const tracking = "[branch \"feature\"]\n\tremote = origin\n\tmerge = refs/heads/main\n"
+	const trackingSame = "[branch \"feature\"]\n\tremote = origin\n\tmerge = refs/heads/feature\n"
+
+	tests := []struct {
+		name       string
+		config     string
+		userConfig string
+		want       string
+	}{
+		{name: "simple with same-named upstream", config: remotesConfig + trackingSame, want: "refs/remotes/origin/feature"},
+		{name: "simple with differently named upstream", config: remotesConfig + tracking, want: ""},
+		{name: "simple without upstream", config: remotesConfig, want: ""},
+		{
+			name:   "simple to pushRemote",
+			config: remotesConfig + tracking + "[branch \"feature\"]\n\tpushRemote = fork\n",
+			want:   "refs/remotes/fork/feature",
+		},
+		{
+			name:       "remote.pushDefault from user config",
+			config:     remotesConfig + tracking,
+			userConfig: "[remote]\n\tpushDefault = fork\n",
+			want:       "refs/remotes/fork/feature",
+		},
+		{
+			name:       "upstream mode",
+			config:     remotesConfig + tracking,
+			userConfig: "[push]\n\tdefault = upstream\n",
+			want:       "refs/remotes/origin/main",
+		},
+		{
+			name:       "current mode",
+			config:     remotesConfig + tracking,
+			userConfig: "[push]\n\tdefault = current\n",
+			want:       "refs/remotes/origin/feature",
+		},
+		{
+			name:       "repository config wins over user config",
+			config:     remotesConfig + tracking + "[push]\n\tdefault = nothing\n",
+			userConfig: "[push]\n\tdefault = current\n",
+			want:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userCfg := format.New()
+			if tt.userConfig != "" {
+				userCfg = parseConfig(t, tt.userConfig).Raw
+			}
+
+			ref, ok := resolvePushTarget(parseConfig(t, tt.config), userCfg, "feature")
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, ref.String())
+		})
+	}
+}
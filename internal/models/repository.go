@@ -21,15 +21,32 @@ var (
 	redColor    = color.New(color.FgRed, color.Bold).SprintFunc()
 )
 
+// defaultRemote is the remote name whose tracking branches are not spelled out in Format.
+const defaultRemote = "origin"
+
+// RepositoryKind describes how a working copy is attached to its Git directory.
+type RepositoryKind string
+
+const (
+	// RepositoryKindMain is a regular checkout or bare repository that owns its Git directory.
+	RepositoryKindMain RepositoryKind = "main"
+	// RepositoryKindWorktree is a linked worktree created by "git worktree add".
+	RepositoryKindWorktree RepositoryKind = "worktree"
+	// RepositoryKindSubmodule is a submodule working copy whose Git directory lives in the superproject.
+	RepositoryKindSubmodule RepositoryKind = "submodule"
+)
+
 // Repository represents a Git repository discovered during directory scanning.
 type Repository struct {
-	Path       string     // Absolute file system path to the repository directory
-	Name       string     // Base name of the repository directory
-	IsBare     bool       // Whether the repository is a bare repository
-	IsSymlink  bool       // Whether the repository was reached via a symbolic link
-	GitStatus  *GitStatus // Current Git status information (nil if error occurred)
-	Error      error      // Error encountered during processing
-	HasTimeout bool       // Whether Git operations timed out
+	Path       string         // Absolute file system path to the repository directory
+	Name       string         // Base name of the repository directory
+	IsBare     bool           // Whether the repository is a bare repository
+	IsSymlink  bool           // Whether the repository was reached via a symbolic link
+	Kind       RepositoryKind // Main checkout, linked worktree or submodule (empty is treated as main)
+	GitDir     string         // Resolved Git directory (differs from Path/.git when .git is a pointer file)
+	GitStatus  *GitStatus     // Current Git status information (nil if error occurred)
+	Error      error          // Error encountered during processing
+	HasTimeout bool           // Whether Git operations timed out
 }
 
 var (
@@ -55,16 +72,345 @@ func (r *Repository) Validate() error {
 	return nil
 }
 
+// IsLinked returns true if the repository's .git entry is a pointer file to a Git directory
+// owned by another repository (a linked worktree or a submodule).
+func (r *Repository) IsLinked() bool {
+	return r.Kind == RepositoryKindWorktree || r.Kind == RepositoryKindSubmodule
+}
+
 // GitStatus represents the Git status information for a repository.
 type GitStatus struct {
-	Branch     string // Current branch name or "DETACHED" if HEAD is detached
-	IsDetached bool   // Whether HEAD is in detached state
-	HasRemote  bool   // Whether repository has a remote configured
-	Ahead      int    // Number of commits ahead of remote
-	Behind     int    // Number of commits behind remote
-	HasStashes bool   // Whether repository has stashed changes
-	HasChanges bool   // Whether repository has uncommitted changes
-	Error      string // Partial error message if some status info couldn't be retrieved
+	Branch        string       // Current branch name or "DETACHED" if HEAD is detached
+	DefaultBranch string       // Branch the repository's work is based on, e.g. "main" or "develop" ("" if unknown)
+	IsDetached    bool         // Whether HEAD is in detached state
+	HasRemote     bool         // Whether repository has a remote configured
+	Ahead         int          // Number of commits ahead of remote
+	Behind        int          // Number of commits behind remote
+	Upstream      string       // Upstream branch ahead/behind are counted against, e.g. "origin/main" ("" if none is configured)
+	PushTarget    string       // Remote branch "git push" would update, e.g. "fork/feature" ("" if pushing would fail)
+	NoUpstream    bool         // Whether the branch has no upstream although remotes exist (local-only, never pushed with -u)
+	UpstreamGone  bool         // Whether the configured upstream no longer exists (deleted on the remote, or its remote removed)
+	HasStashes    bool         // Whether repository has stashed changes
+	StashCount    int          // Number of stashes (0 if there are none, or if HasStashes but unknown)
+	OldestStash   time.Time    // When the oldest stash was made (zero if unknown)
+	HasChanges    bool         // Whether repository has uncommitted changes
+	Changes       ChangeCounts // Uncommitted changes by category (all zero without HasChanges)
+	Error         string       // Partial error message if some status info couldn't be retrieved
+
+	LastCommit   *CommitInfo // Commit HEAD points to (nil if there is none, e.g. no commits yet)
+	LastActivity time.Time   // Most recent committer date of HEAD and the local branches (zero if unknown)
+	IsStale      bool        // Whether HEAD and the local branches have not changed for longer than the staleness threshold
+
+	Operation      Operation // Multi-step command stopped midway (merge, rebase, ...), empty if none
+	OperationStep  int       // Current step of a rebase or am (0 if unknown)
+	OperationTotal int       // Number of steps of a rebase or am (0 if unknown)
+
+	Branches []BranchStatus // Local branches other than the current one, by name (nil unless all branches were audited)
+
+	LFS *LFSStatus // Git LFS content not downloaded or not uploaded (nil if the repository does not use Git LFS)
+
+	Submodules []SubmoduleStatus // Submodules declared in .gitmodules and recorded in the index, by path (nil if none)
+}
+
+// SubmoduleStatus is how a submodule's working copy compares with the commit its
+// superproject records for it.
+type SubmoduleStatus struct {
+	Path          string // Path in the superproject's working tree, e.g. "libs/core"
+	Recorded      string // Abbreviated commit the superproject records (the gitlink in its index)
+	Current       string // Abbreviated commit checked out in the submodule ("" if uninitialized)
+	Uninitialized bool   // Whether the submodule is not checked out ("git submodule update --init" was not run)
+	OutOfSync     bool   // Whether another commit than the recorded one is checked out
+	Modified      bool   // Whether the submodule has uncommitted changes
+}
+
+// NeedsAttention reports whether the submodule is not checked out as the superproject expects.
+func (s *SubmoduleStatus) NeedsAttention() bool {
+	return s.Uninitialized || s.OutOfSync || s.Modified
+}
+
+// Format returns the state of the submodule, or "" if it needs no attention:
+//   - [[ uninitialized ]] - Not checked out
+//   - [[ out of sync | * ]] - Another commit checked out, and uncommitted changes
+func (s *SubmoduleStatus) Format() string {
+	var parts []string
+	switch {
+	case s.Uninitialized:
+		parts = append(parts, redColor("uninitialized"))
+	case s.OutOfSync:
+		parts = append(parts, redColor("out of sync"))
+	}
+	if s.Modified {
+		parts = append(parts, redColor("*"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+
+	return yellowColor("[[") + " " + strings.Join(parts, " "+grayColor("|")+" ") + " " + yellowColor("]]")
+}
+
+// FormatRecorded returns "out of sync" if another commit than the recorded one is
+// checked out, or "". It follows the status of a submodule scanned as a repository,
+// which shows uncommitted changes itself but cannot tell what the superproject expects.
+func (s *SubmoduleStatus) FormatRecorded() string {
+	if !s.OutOfSync {
+		return ""
+	}
+
+	return redColor("out of sync")
+}
+
+// LFSStatus is the state of the Git LFS content of a repository, which "git status"
+// does not show: a repository can look clean while its LFS content is incomplete.
+type LFSStatus struct {
+	Unfetched int // Files checked out as LFS pointers, their content never downloaded ("git lfs pull")
+	Unpushed  int // LFS objects of unpushed commits, stored only locally until "git push" uploads them
+}
+
+// NeedsAttention reports whether LFS content is missing locally or on the remote.
+// A nil LFSStatus (no Git LFS) never does.
+func (l *LFSStatus) NeedsAttention() bool {
+	return l != nil && (l.Unfetched > 0 || l.Unpushed > 0)
+}
+
+// Format returns the LFS indicator, e.g. "lfs↑2↓3" for 2 objects to push and 3 files
+// to pull, or "" if nothing is missing.
+func (l *LFSStatus) Format() string {
+	if !l.NeedsAttention() {
+		return ""
+	}
+
+	indicator := "lfs"
+	if l.Unpushed > 0 {
+		indicator += fmt.Sprintf("↑%d", l.Unpushed)
+	}
+	if l.Unfetched > 0 {
+		indicator += fmt.Sprintf("↓%d", l.Unfetched)
+	}
+
+	return indicator
+}
+
+// BranchStatus is how a local branch that is not checked out compares with its
+// upstream and the default branch.
+type BranchStatus struct {
+	Name         string // Branch name
+	Upstream     string // Upstream branch, e.g. "origin/feature" ("" if none is configured)
+	Ahead        int    // Number of commits ahead of the upstream
+	Behind       int    // Number of commits behind the upstream
+	NoUpstream   bool   // Whether the branch has no upstream although remotes exist (never pushed with -u)
+	UpstreamGone bool   // Whether the configured upstream no longer exists
+	Merged       bool   // Whether the branch is fully merged into the default branch (safe to delete)
+}
+
+// NeedsAttention reports whether the branch may hold work that exists nowhere else:
+// commits not pushed to its upstream, or no upstream at all, unless it is merged.
+func (b *BranchStatus) NeedsAttention() bool {
+	return !b.Merged && (b.Ahead > 0 || b.NoUpstream || b.UpstreamGone)
+}
+
+// Format returns the branch with its state, using the symbols of GitStatus.Format:
+//   - feature [[ ↑2 ↓1 ]] - 2 commits to push, 1 to pull
+//   - wip [[ ◇ ]] - Never pushed
+//   - old [[ ⊘ | merged ]] - Upstream deleted, but merged into the default branch
+//   - topic - In sync with its upstream
+func (b *BranchStatus) Format() string {
+	var parts []string
+	if b.Upstream != "" && b.Upstream != defaultRemote+"/"+b.Name {
+		parts = append(parts, grayColor("→ "+b.Upstream))
+	}
+	if b.Ahead > 0 {
+		parts = append(parts, greenColor(fmt.Sprintf("↑%d", b.Ahead)))
+	}
+	if b.Behind > 0 {
+		parts = append(parts, redColor(fmt.Sprintf("↓%d", b.Behind)))
+	}
+	switch {
+	case b.UpstreamGone:
+		parts = append(parts, redColor("⊘"))
+	case b.NoUpstream:
+		parts = append(parts, redColor("◇"))
+	}
+
+	nameColor, bracketColor := grayColor, grayColor
+	if b.NeedsAttention() {
+		nameColor, bracketColor = yellowColor, yellowColor
+	}
+
+	status := strings.Join(parts, " ")
+	if b.Merged {
+		if status != "" {
+			status += " " + grayColor("|") + " "
+		}
+		status += grayColor("merged")
+	}
+	if status == "" {
+		return nameColor(b.Name)
+	}
+
+	return nameColor(b.Name) + " " + bracketColor("[[") + " " + status + " " + bracketColor("]]")
+}
+
+// CommitInfo summarizes a commit for display.
+type CommitInfo struct {
+	Hash    string    // Abbreviated commit hash
+	Author  string    // Author name
+	Date    time.Time // Committer date: when the commit was last created or rewritten
+	Subject string    // First line of the commit message
+}
+
+// Format returns the commit as "<hash> · <relative date> · <author> · <subject>",
+// with dates relative to now and long subjects shortened.
+func (c *CommitInfo) Format(now time.Time) string {
+	subject := []rune(c.Subject)
+	if len(subject) > maxSubjectLength {
+		subject = append(subject[:maxSubjectLength-1], '…')
+	}
+
+	return strings.Join([]string{
+		yellowColor(c.Hash),
+		RelativeTime(c.Date, now),
+		c.Author,
+		string(subject),
+	}, grayColor(" · "))
+}
+
+// maxSubjectLength is the number of characters of a commit subject CommitInfo.Format shows.
+const maxSubjectLength = 50
+
+// RelativeTime describes how long before now t is, like git's relative dates:
+// "5 minutes ago", "3 weeks ago", "2 years ago".
+func RelativeTime(t, now time.Time) string {
+	age := now.Sub(t)
+	if age < 0 {
+		return "in the future"
+	}
+
+	const (
+		day   = 24 * time.Hour
+		week  = 7 * day
+		month = 30 * day
+		year  = 365 * day
+	)
+
+	switch {
+	case age < 90*time.Second:
+		return plural(int(age/time.Second), "second")
+	case age < 90*time.Minute:
+		return plural(int((age+time.Minute/2)/time.Minute), "minute")
+	case age < 36*time.Hour:
+		return plural(int((age+time.Hour/2)/time.Hour), "hour")
+	case age < 14*day:
+		return plural(int((age+day/2)/day), "day")
+	case age < 10*week:
+		return plural(int((age+week/2)/week), "week")
+	case age < year:
+		return plural(int((age+month/2)/month), "month")
+	default:
+		return plural(int(age/year), "year")
+	}
+}
+
+// plural formats "<n> <unit>s ago", without the "s" for one.
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit + " ago"
+	}
+
+	return fmt.Sprintf("%d %ss ago", n, unit)
+}
+
+// Operation is a Git command that stops midway for the user to resolve conflicts or
+// edit commits, leaving the repository in an intermediate state until it is continued
+// or aborted.
+type Operation string
+
+const (
+	// OperationMerge is a "git merge" waiting for conflicts to be resolved and committed.
+	OperationMerge Operation = "merge"
+	// OperationRebase is a "git rebase" stopped at a conflict or edit.
+	OperationRebase Operation = "rebase"
+	// OperationCherryPick is a "git cherry-pick" stopped at a conflict.
+	OperationCherryPick Operation = "cherry-pick"
+	// OperationRevert is a "git revert" stopped at a conflict.
+	OperationRevert Operation = "revert"
+	// OperationBisect is a "git bisect" session that has not been reset.
+	OperationBisect Operation = "bisect"
+	// OperationAm is a "git am" stopped at a patch that does not apply.
+	OperationAm Operation = "am"
+)
+
+// OperationLabel returns the in-progress operation as git's prompt shows it,
+// e.g. "REBASING 3/7" or "MERGING", or "" if there is none.
+func (g *GitStatus) OperationLabel() string {
+	var label string
+	switch g.Operation {
+	case "":
+		return ""
+	case OperationMerge:
+		label = "MERGING"
+	case OperationRebase:
+		label = "REBASING"
+	case OperationCherryPick:
+		label = "CHERRY-PICKING"
+	case OperationRevert:
+		label = "REVERTING"
+	case OperationBisect:
+		label = "BISECTING"
+	case OperationAm:
+		label = "AM"
+	default:
+		label = strings.ToUpper(string(g.Operation))
+	}
+
+	if g.OperationTotal > 0 {
+		label += fmt.Sprintf(" %d/%d", g.OperationStep, g.OperationTotal)
+	}
+
+	return label
+}
+
+// ChangeCounts counts uncommitted changes by category, as "git status --short" reports
+// them. A file staged and then modified again counts as both staged and modified.
+type ChangeCounts struct {
+	Staged    int // Files with changes in the index (added, modified or deleted)
+	Modified  int // Tracked files modified in the working tree but not staged
+	Deleted   int // Tracked files deleted from the working tree but not staged
+	Renamed   int // Files renamed in the index
+	Untracked int // Files not tracked and not ignored
+	Unmerged  int // Files with unresolved merge conflicts
+}
+
+// Total returns the number of changes across all categories.
+func (c ChangeCounts) Total() int {
+	return c.Staged + c.Modified + c.Deleted + c.Renamed + c.Untracked + c.Unmerged
+}
+
+// ChangesMode controls how uncommitted changes are shown by GitStatus.FormatWith.
+type ChangesMode string
+
+const (
+	// ChangesSummary shows a single "*" for any uncommitted changes (default).
+	ChangesSummary ChangesMode = "summary"
+	// ChangesDetailed shows counts per category, e.g. "+3 ~2 ?5 !1".
+	ChangesDetailed ChangesMode = "detailed"
+)
+
+var errInvalidChangesMode = errors.New("invalid changes mode")
+
+// ParseChangesMode converts a mode name into a ChangesMode value.
+func ParseChangesMode(value string) (ChangesMode, error) {
+	switch mode := ChangesMode(value); mode {
+	case ChangesSummary, ChangesDetailed:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%w: %q (must be %s or %s)", errInvalidChangesMode, value, ChangesSummary, ChangesDetailed)
+	}
+}
+
+// StatusFormat configures GitStatus.FormatWith. The zero value gives the default output.
+type StatusFormat struct {
+	Changes ChangesMode // How uncommitted changes are shown (empty = ChangesSummary)
 }
 
 var errGitStatusValidation = errors.New("git status validation error")
@@ -83,15 +429,105 @@ func (g *GitStatus) Validate() error {
 	if g.Ahead < 0 || g.Behind < 0 {
 		return fmt.Errorf("ahead/behind counts cannot be negative: %w", errGitStatusValidation)
 	}
+	if g.NoUpstream && (g.UpstreamGone || g.Upstream != "") {
+		return fmt.Errorf("branch without upstream cannot have an upstream: %w", errGitStatusValidation)
+	}
+	c := g.Changes
+	if c.Staged < 0 || c.Modified < 0 || c.Deleted < 0 || c.Renamed < 0 || c.Untracked < 0 || c.Unmerged < 0 {
+		return fmt.Errorf("change counts cannot be negative: %w", errGitStatusValidation)
+	}
+	if !g.HasChanges && c.Total() > 0 {
+		return fmt.Errorf("no changes but change counts are non-zero: %w", errGitStatusValidation)
+	}
+	if g.StashCount < 0 || (g.StashCount > 0 && !g.HasStashes) {
+		return fmt.Errorf("stash count must be 0 without stashes and cannot be negative: %w", errGitStatusValidation)
+	}
+	if g.OperationStep < 0 || g.OperationStep > g.OperationTotal {
+		return fmt.Errorf("operation step must be between 0 and the number of steps: %w", errGitStatusValidation)
+	}
+	if g.Operation == "" && g.OperationTotal != 0 {
+		return fmt.Errorf("operation steps without an operation: %w", errGitStatusValidation)
+	}
+	for _, branch := range g.Branches {
+		if branch.Name == "" || branch.Ahead < 0 || branch.Behind < 0 {
+			return fmt.Errorf("branches must have a name and non-negative ahead/behind counts: %w", errGitStatusValidation)
+		}
+	}
+	if g.LFS != nil && (g.LFS.Unfetched < 0 || g.LFS.Unpushed < 0) {
+		return fmt.Errorf("LFS counts cannot be negative: %w", errGitStatusValidation)
+	}
+	for _, sub := range g.Submodules {
+		if sub.Path == "" || sub.Uninitialized && (sub.OutOfSync || sub.Modified || sub.Current != "") {
+			return fmt.Errorf("submodules must have a path, and nothing checked out if uninitialized: %w", errGitStatusValidation)
+		}
+	}
+	if g.IsStale && g.LastActivity.IsZero() {
+		return fmt.Errorf("stale repository must have a last activity date: %w", errGitStatusValidation)
+	}
 
 	return nil
 }
 
+// HasStashOlderThan reports whether the oldest stash was made more than age before now.
+func (g *GitStatus) HasStashOlderThan(age time.Duration, now time.Time) bool {
+	return g.HasStashes && !g.OldestStash.IsZero() && now.Sub(g.OldestStash) > age
+}
+
+// InactiveFor reports whether HEAD and the local branches last changed more than age before now.
+func (g *GitStatus) InactiveFor(age time.Duration, now time.Time) bool {
+	return !g.LastActivity.IsZero() && now.Sub(g.LastActivity) > age
+}
+
+// IsOnDefaultBranch reports whether HEAD is on the repository's default branch.
+// If the default branch is unknown, main and master count as default.
+func (g *GitStatus) IsOnDefaultBranch() bool {
+	if g.IsDetached {
+		return false
+	}
+	if g.DefaultBranch != "" {
+		return g.Branch == g.DefaultBranch
+	}
+
+	return g.Branch == "main" || g.Branch == "master" //nolint:goconst // "main" and "master" are domain literals
+}
+
+// hasBranchNeedingAttention reports whether another local branch may hold work that exists nowhere else.
+func (g *GitStatus) hasBranchNeedingAttention() bool {
+	for i := range g.Branches {
+		if g.Branches[i].NeedsAttention() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// submodulesNeedingAttention counts the submodules not checked out as the superproject expects.
+func (g *GitStatus) submodulesNeedingAttention() int {
+	count := 0
+	for i := range g.Submodules {
+		if g.Submodules[i].NeedsAttention() {
+			count++
+		}
+	}
+
+	return count
+}
+
 // IsStandardStatus returns true if the repository is in a standard state.
 func (g *GitStatus) IsStandardStatus() bool {
-	// Standard state: on main/master, in sync with remote, no stashes, no changes, no error
-	return (g.Branch == "main" || g.Branch == "master") && //nolint:goconst // "main" and "master" are domain literals
+	// Standard state: on the default branch, in sync with an existing upstream, no stashes,
+	// no changes, no operation in progress, not stale, no unpushed work on other branches,
+	// no missing LFS content, submodules checked out as recorded, no error
+	return g.IsOnDefaultBranch() &&
+		!g.hasBranchNeedingAttention() &&
+		!g.LFS.NeedsAttention() &&
+		g.submodulesNeedingAttention() == 0 &&
+		g.Operation == "" &&
+		!g.IsStale &&
 		g.HasRemote &&
+		!g.NoUpstream &&
+		!g.UpstreamGone &&
 		g.Ahead == 0 &&
 		g.Behind == 0 &&
 		!g.HasStashes &&
@@ -101,26 +537,47 @@ func (g *GitStatus) IsStandardStatus() bool {
 
 // Format returns the formatted Git status string for display with colorization.
 func (g *GitStatus) Format() string {
+	return g.FormatWith(StatusFormat{})
+}
+
+// FormatWith is Format with display options.
+func (g *GitStatus) FormatWith(opts StatusFormat) string {
 	// Examples (with colors disabled):
-	//   - [[ main ]] - On main, in sync with remote, no changes (gray brackets)
+	//   - [[ main ]] - On the default branch, in sync with remote, no changes (gray brackets)
 	//   - [[ main | ↑2 ↓1 ]] - 2 commits ahead, 1 behind (yellow brackets)
-	//   - [[ develop | $ * ]] - Has stashes and uncommitted changes (yellow brackets)
+	//   - [[ develop | $2 * ]] - Has 2 stashes and uncommitted changes (yellow brackets)
+	//   - [[ develop | +3 ~2 ?5 !1 ]] - Detailed changes: staged, modified, untracked, unmerged
 	//   - [[ DETACHED ]] - Detached HEAD state (yellow brackets)
+	//   - [[ DETACHED | REBASING 3/7 | * ]] - Rebase stopped at step 3 of 7 (red, yellow brackets)
 	//   - [[ main | ○ ]] - No remote configured (yellow brackets)
+	//   - [[ feature | ◇ ]] - Remotes exist, but the branch has no upstream (yellow brackets)
+	//   - [[ feature | ⊘ ]] - Upstream configured but gone from the remote (yellow brackets)
+	//   - [[ main | lfs↑2↓3 ]] - 2 LFS objects not pushed, 3 LFS files not pulled (yellow brackets)
+	//   - [[ main | sub:2 ]] - 2 submodules uninitialized, modified or out of sync (yellow brackets)
+	//   - [[ main | ⧗ ]] - Stale: nothing committed for longer than the threshold (yellow brackets)
+	//   - [[ feature → upstream/main ⇡ origin/feature | ↑1 ]] - Upstream and push target (gray)
 	//   - [[ main | error ]] - Partial error retrieving status (yellow brackets)
 	//   - [[ N/A | error ]] - Error retrieving status (N/A and error are red, yellow brackets)
 	var parts []string
 
-	// Branch: gray for main/master, red for N/A, yellow otherwise
-	switch g.Branch {
-	case "main", "master":
-		parts = append(parts, grayColor(g.Branch))
-	case "N/A":
+	// Branch: gray for the default branch, red for N/A, yellow otherwise
+	switch {
+	case g.Branch == "N/A":
 		parts = append(parts, redColor(g.Branch))
+	case g.IsOnDefaultBranch():
+		parts = append(parts, grayColor(g.Branch))
 	default:
 		parts = append(parts, yellowColor(g.Branch))
 	}
 
+	// Upstream and push target: gray, omitted when they are the conventional origin/<branch>
+	if g.Upstream != "" && g.Upstream != defaultRemote+"/"+g.Branch {
+		parts[0] += " " + grayColor("→ "+g.Upstream)
+	}
+	if g.Upstream != "" && g.PushTarget != "" && g.PushTarget != g.Upstream {
+		parts[0] += " " + grayColor("⇡ "+g.PushTarget)
+	}
+
 	// Ahead/Behind: green/red, or gray no-remote indicator
 	if g.HasRemote {
 		if g.Ahead > 0 {
@@ -129,19 +586,49 @@ func (g *GitStatus) Format() string {
 		if g.Behind > 0 {
 			parts = append(parts, redColor(fmt.Sprintf("↓%d", g.Behind)))
 		}
+		// Local-only or orphaned branch: red, commits may exist nowhere else
+		switch {
+		case g.UpstreamGone:
+			parts = append(parts, redColor("⊘"))
+		case g.NoUpstream:
+			parts = append(parts, redColor("◇"))
+		}
 	} else if g.Error == "" {
 		// Only show no-remote indicator if there's no error
 		parts = append(parts, yellowColor("○"))
 	}
 
-	// Stashes: red
+	// Stashes: red, with their number when known
 	if g.HasStashes {
-		parts = append(parts, redColor("$"))
+		if g.StashCount > 0 {
+			parts = append(parts, redColor(fmt.Sprintf("$%d", g.StashCount)))
+		} else {
+			parts = append(parts, redColor("$"))
+		}
 	}
 
-	// Uncommitted changes: red
+	// Uncommitted changes: red (staged changes green in detailed mode)
 	if g.HasChanges {
-		parts = append(parts, redColor("*"))
+		if opts.Changes == ChangesDetailed && g.Changes.Total() > 0 {
+			parts = append(parts, g.Changes.format()...)
+		} else {
+			parts = append(parts, redColor("*"))
+		}
+	}
+
+	// Missing LFS content: red, the working tree or the remote lacks real files
+	if lfs := g.LFS.Format(); lfs != "" {
+		parts = append(parts, redColor(lfs))
+	}
+
+	// Submodules not checked out as recorded: red
+	if n := g.submodulesNeedingAttention(); n > 0 {
+		parts = append(parts, redColor(fmt.Sprintf("sub:%d", n)))
+	}
+
+	// Stale repository: yellow, possibly an abandoned clone
+	if g.IsStale {
+		parts = append(parts, yellowColor("⧗"))
 	}
 
 	// Error indicator: red (added as status indicator)
@@ -149,6 +636,12 @@ func (g *GitStatus) Format() string {
 		parts = append(parts, redColor("error"))
 	}
 
+	// In-progress operation: red, in its own section right after the branch
+	separator := " " + grayColor("|") + " "
+	if label := g.OperationLabel(); label != "" {
+		parts[0] += separator + redColor(label)
+	}
+
 	// Build result with brackets (yellow for non-standard status, gray for standard) and separator
 	bracketColor := grayColor
 	if !g.IsStandardStatus() {
@@ -161,7 +654,6 @@ func (g *GitStatus) Format() string {
 		result = bracketColor("[[") + " " + parts[0] + " " + bracketColor("]]")
 	} else {
 		// Branch + status indicators, use separator
-		separator := " " + grayColor("|") + " "
 		statusParts := strings.Join(parts[1:], " ")
 		result = bracketColor("[[") + " " + parts[0] + separator + statusParts + " " + bracketColor("]]")
 	}
@@ -169,6 +661,30 @@ func (g *GitStatus) Format() string {
 	return result
 }
 
+// format returns the non-zero counts with their symbols: + staged, ~ modified,
+// - deleted, » renamed, ? untracked and ! unmerged.
+func (c ChangeCounts) format() []string {
+	var parts []string
+	for _, count := range []struct {
+		symbol string
+		n      int
+		color  func(a ...any) string
+	}{
+		{"+", c.Staged, greenColor},
+		{"~", c.Modified, redColor},
+		{"-", c.Deleted, redColor},
+		{"»", c.Renamed, greenColor},
+		{"?", c.Untracked, redColor},
+		{"!", c.Unmerged, redColor},
+	} {
+		if count.n > 0 {
+			parts = append(parts, count.color(fmt.Sprintf("%s%d", count.symbol, count.n)))
+		}
+	}
+
+	return parts
+}
+
 // TreeNode represents a node in the hierarchical tree structure.
 type TreeNode struct {
 	Repository   *Repository // The repository at this tree node
@@ -176,6 +692,8 @@ type TreeNode struct {
 	IsLast       bool        // Whether this is the last child of its parent
 	Children     []*TreeNode // Child nodes (nested repositories)
 	RelativePath string      // Path relative to scan root
+
+	Submodule *SubmoduleStatus // State recorded by the superproject, for submodule nodes (see tree.FormatOptions.ShowSubmodules)
 }
 
 var errTreeNodeValidation = errors.New("tree node validation error")
@@ -215,13 +733,15 @@ func (t *TreeNode) SortChildren() {
 
 // ScanResult represents the complete result of a directory scan operation.
 type ScanResult struct {
-	RootPath     string        // Absolute path where scan started
-	Repositories []*Repository // All repositories found during scan
-	Tree         *TreeNode     // Root node of the tree structure
-	TotalScanned int           // Total number of directories scanned
-	TotalRepos   int           // Total number of Git repositories found
-	Errors       []error       // Collection of non-fatal errors
-	Duration     time.Duration // Time taken to complete scan
+	RootPath      string        // Absolute path where scan started
+	Repositories  []*Repository // All repositories found during scan
+	Tree          *TreeNode     // Root node of the tree structure
+	TotalScanned  int           // Total number of directories scanned
+	TotalSkipped  int           // Total number of directories skipped by exclusion rules
+	SkippedMounts []string      // Mount points not descended into (other, pseudo or network file systems)
+	TotalRepos    int           // Total number of Git repositories found
+	Errors        []error       // Collection of non-fatal errors
+	Duration      time.Duration // Time taken to complete scan
 }
 
 // Validate checks if the ScanResult meets all validation rules.
@@ -244,6 +764,9 @@ func (s *ScanResult) Validate() error {
 	if s.TotalScanned < s.TotalRepos {
 		return fmt.Errorf("total scanned < total repos: %d < %d: %w", s.TotalScanned, s.TotalRepos, errScanResultValidation)
 	}
+	if s.TotalSkipped < 0 {
+		return fmt.Errorf("total skipped cannot be negative: %d: %w", s.TotalSkipped, errScanResultValidation)
+	}
 	if s.Duration < 0 {
 		return fmt.Errorf("duration cannot be negative: %w", errScanResultValidation)
 	}
@@ -3,6 +3,7 @@ package models
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -156,6 +157,94 @@ func TestGitStatusValidation(t *testing.T) {
 			expectError: true,
 			errorMsg:    "ahead/behind counts cannot be negative",
 		},
+		{
+			name: "no upstream but upstream gone",
+			status: GitStatus{
+				Branch:       "feature",
+				HasRemote:    true,
+				NoUpstream:   true,
+				UpstreamGone: true,
+			},
+			expectError: true,
+			errorMsg:    "branch without upstream cannot have an upstream",
+		},
+		{
+			name: "change counts without changes",
+			status: GitStatus{
+				Branch:  "main",
+				Changes: ChangeCounts{Untracked: 1},
+			},
+			expectError: true,
+			errorMsg:    "no changes but change counts are non-zero",
+		},
+		{
+			name: "negative change count",
+			status: GitStatus{
+				Branch:     "main",
+				HasChanges: true,
+				Changes:    ChangeCounts{Modified: -1},
+			},
+			expectError: true,
+			errorMsg:    "change counts cannot be negative",
+		},
+		{
+			name: "stash count without stashes",
+			status: GitStatus{
+				Branch:     "main",
+				StashCount: 2,
+			},
+			expectError: true,
+			errorMsg:    "stash count must be 0 without stashes",
+		},
+		{
+			name: "operation step past the last step",
+			status: GitStatus{
+				Branch:         "DETACHED",
+				IsDetached:     true,
+				Operation:      OperationRebase,
+				OperationStep:  4,
+				OperationTotal: 3,
+			},
+			expectError: true,
+			errorMsg:    "operation step must be between 0 and the number of steps",
+		},
+		{
+			name: "operation steps without operation",
+			status: GitStatus{
+				Branch:         "main",
+				OperationStep:  1,
+				OperationTotal: 3,
+			},
+			expectError: true,
+			errorMsg:    "operation steps without an operation",
+		},
+		{
+			name: "stale without last activity",
+			status: GitStatus{
+				Branch:  "main",
+				IsStale: true,
+			},
+			expectError: true,
+			errorMsg:    "stale repository must have a last activity date",
+		},
+		{
+			name: "negative LFS counts",
+			status: GitStatus{
+				Branch: "main",
+				LFS:    &LFSStatus{Unfetched: -1},
+			},
+			expectError: true,
+			errorMsg:    "LFS counts cannot be negative",
+		},
+		{
+			name: "uninitialized submodule with a checked out commit",
+			status: GitStatus{
+				Branch:     "main",
+				Submodules: []SubmoduleStatus{{Path: "lib", Recorded: "abc1234", Current: "abc1234", Uninitialized: true}},
+			},
+			expectError: true,
+			errorMsg:    "nothing checked out if uninitialized",
+		},
 	}
 
 	for _, tt := range tests {
@@ -452,6 +541,23 @@ func TestScanResultValidation(t *testing.T) {
 			expectError: true,
 			errorMsg:    "duration cannot be negative",
 		},
+		{
+			name: "negative skipped count",
+			result: ScanResult{
+				RootPath:     "/home/user",
+				Repositories: []*Repository{},
+				Tree: &TreeNode{
+					Repository: &Repository{
+						Path: "/home/user",
+						Name: "user",
+					},
+					RelativePath: ".",
+				},
+				TotalSkipped: -1,
+			},
+			expectError: true,
+			errorMsg:    "total skipped cannot be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -649,6 +755,128 @@ func TestGitStatusFormat(t *testing.T) {
 			},
 			expected: "[[ N/A | error ]]",
 		},
+		{
+			name: "conventional upstream is omitted",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				Upstream:   "origin/main",
+				PushTarget: "origin/main",
+			},
+			expected: "[[ main ]]",
+		},
+		{
+			name: "other upstream",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				Behind:     4,
+				Upstream:   "upstream/main",
+				PushTarget: "upstream/main",
+			},
+			expected: "[[ main → upstream/main | ↓4 ]]",
+		},
+		{
+			name: "upstream and push target",
+			status: GitStatus{
+				Branch:     "feature",
+				HasRemote:  true,
+				Ahead:      1,
+				Upstream:   "upstream/main",
+				PushTarget: "origin/feature",
+			},
+			expected: "[[ feature → upstream/main ⇡ origin/feature | ↑1 ]]",
+		},
+		{
+			name: "no upstream",
+			status: GitStatus{
+				Branch:     "feature",
+				HasRemote:  true,
+				NoUpstream: true,
+			},
+			expected: "[[ feature | ◇ ]]",
+		},
+		{
+			name: "upstream gone",
+			status: GitStatus{
+				Branch:       "feature",
+				HasRemote:    true,
+				Upstream:     "origin/feature",
+				UpstreamGone: true,
+			},
+			expected: "[[ feature | ⊘ ]]",
+		},
+		{
+			name: "stale",
+			status: GitStatus{
+				Branch:       "main",
+				HasRemote:    true,
+				LastActivity: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				IsStale:      true,
+			},
+			expected: "[[ main | ⧗ ]]",
+		},
+		{
+			name: "stash count",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				HasStashes: true,
+				StashCount: 3,
+			},
+			expected: "[[ main | $3 ]]",
+		},
+		{
+			name: "rebase in progress",
+			status: GitStatus{
+				Branch:         "DETACHED",
+				IsDetached:     true,
+				HasRemote:      true,
+				HasChanges:     true,
+				Operation:      OperationRebase,
+				OperationStep:  3,
+				OperationTotal: 7,
+			},
+			expected: "[[ DETACHED | REBASING 3/7 | * ]]",
+		},
+		{
+			name: "merge in progress",
+			status: GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				Operation: OperationMerge,
+			},
+			expected: "[[ main | MERGING ]]",
+		},
+		{
+			name: "change counts are summarized by default",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				HasChanges: true,
+				Changes:    ChangeCounts{Staged: 3, Untracked: 5},
+			},
+			expected: "[[ main | * ]]",
+		},
+		{
+			name: "LFS content missing",
+			status: GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				Ahead:     1,
+				LFS:       &LFSStatus{Unfetched: 3, Unpushed: 2},
+			},
+			expected: "[[ main | ↑1 lfs↑2↓3 ]]",
+		},
+		{
+			name: "LFS content complete",
+			status: GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				LFS:       &LFSStatus{},
+			},
+			expected: "[[ main ]]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -659,6 +887,152 @@ func TestGitStatusFormat(t *testing.T) {
 	}
 }
 
+func TestGitStatusFormatDetailedChanges(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	detailed := StatusFormat{Changes: ChangesDetailed}
+
+	tests := []struct {
+		name     string
+		status   GitStatus
+		expected string
+	}{
+		{
+			name: "all categories",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				HasChanges: true,
+				Changes:    ChangeCounts{Staged: 3, Modified: 2, Deleted: 1, Renamed: 4, Untracked: 5, Unmerged: 1},
+			},
+			expected: "[[ main | +3 ~2 -1 »4 ?5 !1 ]]",
+		},
+		{
+			name: "zero counts are omitted",
+			status: GitStatus{
+				Branch:     "feature",
+				HasRemote:  true,
+				Ahead:      1,
+				HasStashes: true,
+				HasChanges: true,
+				Changes:    ChangeCounts{Modified: 2, Untracked: 5},
+			},
+			expected: "[[ feature | ↑1 $ ~2 ?5 ]]",
+		},
+		{
+			name: "changes without counts",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				HasChanges: true,
+			},
+			expected: "[[ main | * ]]",
+		},
+		{
+			name:     "clean",
+			status:   GitStatus{Branch: "main", HasRemote: true},
+			expected: "[[ main ]]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.status.FormatWith(detailed))
+		})
+	}
+}
+
+func TestParseChangesMode(t *testing.T) {
+	for _, value := range []string{"summary", "detailed"} {
+		mode, err := ParseChangesMode(value)
+		require.NoError(t, err)
+		assert.Equal(t, ChangesMode(value), mode)
+	}
+
+	_, err := ParseChangesMode("verbose")
+	require.ErrorIs(t, err, errInvalidChangesMode)
+}
+
+func TestOperationLabel(t *testing.T) {
+	tests := []struct {
+		status   GitStatus
+		expected string
+	}{
+		{GitStatus{}, ""},
+		{GitStatus{Operation: OperationMerge}, "MERGING"},
+		{GitStatus{Operation: OperationRebase, OperationStep: 2, OperationTotal: 5}, "REBASING 2/5"},
+		{GitStatus{Operation: OperationRebase}, "REBASING"},
+		{GitStatus{Operation: OperationCherryPick}, "CHERRY-PICKING"},
+		{GitStatus{Operation: OperationRevert}, "REVERTING"},
+		{GitStatus{Operation: OperationBisect}, "BISECTING"},
+		{GitStatus{Operation: OperationAm, OperationStep: 1, OperationTotal: 2}, "AM 1/2"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.status.OperationLabel())
+	}
+}
+
+func TestInactiveFor(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	assert.False(t, (&GitStatus{}).InactiveFor(day, now), "unknown activity is not inactivity")
+	assert.True(t, (&GitStatus{LastActivity: now.Add(-100 * day)}).InactiveFor(90*day, now))
+	assert.False(t, (&GitStatus{LastActivity: now.Add(-80 * day)}).InactiveFor(90*day, now))
+}
+
+func TestRelativeTime(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		age      time.Duration
+		expected string
+	}{
+		{-time.Hour, "in the future"},
+		{0, "0 seconds ago"},
+		{time.Second, "1 second ago"},
+		{45 * time.Second, "45 seconds ago"},
+		{2 * time.Minute, "2 minutes ago"},
+		{89 * time.Minute, "89 minutes ago"},
+		{3 * time.Hour, "3 hours ago"},
+		{35 * time.Hour, "35 hours ago"},
+		{2 * day, "2 days ago"},
+		{13 * day, "13 days ago"},
+		{21 * day, "3 weeks ago"},
+		{69 * day, "10 weeks ago"},
+		{100 * day, "3 months ago"},
+		{364 * day, "12 months ago"},
+		{365 * day, "1 year ago"},
+		{3 * 365 * day, "3 years ago"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, RelativeTime(now.Add(-tt.age), now))
+		})
+	}
+}
+
+func TestCommitInfoFormat(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	commit := &CommitInfo{
+		Hash:    "1a2b3c4",
+		Author:  "Jane Doe",
+		Date:    now.Add(-21 * 24 * time.Hour),
+		Subject: "Fix the frobnicator",
+	}
+	assert.Equal(t, "1a2b3c4 · 3 weeks ago · Jane Doe · Fix the frobnicator", commit.Format(now))
+
+	commit.Subject = strings.Repeat("x", 60)
+	assert.Equal(t, "1a2b3c4 · 3 weeks ago · Jane Doe · "+strings.Repeat("x", 49)+"…", commit.Format(now))
+}
+
 // === User Story 1: Distinguish Repository Metadata from Names ===
 
 // T009 [US1]: Verify output uses double brackets [[ ]] instead of [ ].
@@ -732,6 +1106,45 @@ func TestIsStandardStatus(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "non-standard - main without upstream",
+			status: GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				NoUpstream: true,
+			},
+			expected: false,
+		},
+		{
+			name: "non-standard - stale",
+			status: GitStatus{
+				Branch:       "main",
+				HasRemote:    true,
+				LastActivity: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+				IsStale:      true,
+			},
+			expected: false,
+		},
+		{
+			name: "non-standard - main with upstream gone",
+			status: GitStatus{
+				Branch:       "main",
+				HasRemote:    true,
+				Upstream:     "origin/main",
+				UpstreamGone: true,
+			},
+			expected: false,
+		},
+		{
+			name: "non-standard - main with bisect in progress",
+			status: GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				Upstream:  "origin/main",
+				Operation: OperationBisect,
+			},
+			expected: false,
+		},
 		{
 			name: "non-standard - feature branch",
 			status: GitStatus{
@@ -802,6 +1215,24 @@ func TestIsStandardStatus(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "standard - detected default branch develop",
+			status: GitStatus{
+				Branch:        "develop",
+				DefaultBranch: "develop",
+				HasRemote:     true,
+			},
+			expected: true,
+		},
+		{
+			name: "non-standard - main when the default branch is develop",
+			status: GitStatus{
+				Branch:        "main",
+				DefaultBranch: "develop",
+				HasRemote:     true,
+			},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -812,6 +1243,170 @@ func TestIsStandardStatus(t *testing.T) {
 	}
 }
 
+func TestBranchStatusFormat(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	tests := []struct {
+		name     string
+		branch   BranchStatus
+		expected string
+		attend   bool
+	}{
+		{"in sync", BranchStatus{Name: "topic", Upstream: "origin/topic"}, "topic", false},
+		{"ahead and behind", BranchStatus{Name: "feature", Upstream: "origin/feature", Ahead: 2, Behind: 1}, "feature [[ ↑2 ↓1 ]]", true},
+		{"behind only", BranchStatus{Name: "feature", Upstream: "origin/feature", Behind: 3}, "feature [[ ↓3 ]]", false},
+		{"other upstream", BranchStatus{Name: "fix", Upstream: "fork/fix"}, "fix [[ → fork/fix ]]", false},
+		{"never pushed", BranchStatus{Name: "wip", NoUpstream: true}, "wip [[ ◇ ]]", true},
+		{"upstream gone", BranchStatus{Name: "old", Upstream: "origin/old", UpstreamGone: true}, "old [[ ⊘ ]]", true},
+		{
+			"merged with upstream gone",
+			BranchStatus{Name: "old", Upstream: "origin/old", UpstreamGone: true, Merged: true},
+			"old [[ ⊘ | merged ]]",
+			false,
+		},
+		{"merged", BranchStatus{Name: "done", NoUpstream: true, Merged: true}, "done [[ ◇ | merged ]]", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.branch.Format())
+			assert.Equal(t, tt.attend, tt.branch.NeedsAttention())
+		})
+	}
+}
+
+func TestIsStandardStatus_OtherBranches(t *testing.T) {
+	status := GitStatus{
+		Branch:    "main",
+		HasRemote: true,
+		Branches:  []BranchStatus{{Name: "done", Merged: true, UpstreamGone: true}, {Name: "topic", Behind: 2}},
+	}
+	assert.True(t, status.IsStandardStatus(), "merged and behind-only branches hold no lost work")
+
+	status.Branches = append(status.Branches, BranchStatus{Name: "wip", NoUpstream: true})
+	assert.False(t, status.IsStandardStatus())
+}
+
+func TestLFSStatus(t *testing.T) {
+	var none *LFSStatus
+	assert.False(t, none.NeedsAttention(), "repositories without Git LFS never need attention for it")
+	assert.Empty(t, none.Format())
+
+	tests := []struct {
+		name     string
+		lfs      LFSStatus
+		expected string
+	}{
+		{"complete", LFSStatus{}, ""},
+		{"not pushed", LFSStatus{Unpushed: 2}, "lfs↑2"},
+		{"not pulled", LFSStatus{Unfetched: 5}, "lfs↓5"},
+		{"both", LFSStatus{Unpushed: 1, Unfetched: 4}, "lfs↑1↓4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.lfs.Format())
+			assert.Equal(t, tt.expected != "", tt.lfs.NeedsAttention())
+		})
+	}
+
+	status := GitStatus{Branch: "main", HasRemote: true, LFS: &LFSStatus{}}
+	assert.True(t, status.IsStandardStatus())
+	status.LFS.Unfetched = 1
+	assert.False(t, status.IsStandardStatus())
+}
+
+func TestSubmoduleStatusFormat(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	tests := []struct {
+		name     string
+		sub      SubmoduleStatus
+		expected string
+	}{
+		{"as recorded", SubmoduleStatus{Path: "lib", Recorded: "abc1234", Current: "abc1234"}, ""},
+		{"uninitialized", SubmoduleStatus{Path: "lib", Recorded: "abc1234", Uninitialized: true}, "[[ uninitialized ]]"},
+		{"out of sync", SubmoduleStatus{Path: "lib", Recorded: "abc1234", Current: "def5678", OutOfSync: true}, "[[ out of sync ]]"},
+		{"modified", SubmoduleStatus{Path: "lib", Recorded: "abc1234", Current: "abc1234", Modified: true}, "[[ * ]]"},
+		{
+			"out of sync and modified",
+			SubmoduleStatus{Path: "lib", Recorded: "abc1234", Current: "def5678", OutOfSync: true, Modified: true},
+			"[[ out of sync | * ]]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.sub.Format())
+			assert.Equal(t, tt.expected != "", tt.sub.NeedsAttention())
+		})
+	}
+}
+
+func TestSubmoduleStatusFormatRecorded(t *testing.T) {
+	color.NoColor = false // Enable colors
+
+	sub := SubmoduleStatus{Path: "lib", Recorded: "abc1234", Current: "def5678", OutOfSync: true, Modified: true}
+	assert.Equal(t, redColor("out of sync"), sub.FormatRecorded(), "colored like Format")
+
+	sub = SubmoduleStatus{Path: "lib", Recorded: "abc1234", Current: "abc1234", Modified: true}
+	assert.Empty(t, sub.FormatRecorded(), "uncommitted changes show in the submodule's own status")
+}
+
+func TestGitStatusFormatSubmodules(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	status := GitStatus{
+		Branch:    "main",
+		HasRemote: true,
+		Submodules: []SubmoduleStatus{
+			{Path: "libs/a", Recorded: "abc1234", Current: "abc1234"},
+			{Path: "libs/b", Recorded: "abc1234", Uninitialized: true},
+			{Path: "libs/c", Recorded: "abc1234", Current: "abc1234", Modified: true},
+		},
+	}
+	assert.Equal(t, "[[ main | sub:2 ]]", status.Format())
+	assert.False(t, status.IsStandardStatus())
+
+	status.Submodules = status.Submodules[:1]
+	assert.Equal(t, "[[ main ]]", status.Format())
+	assert.True(t, status.IsStandardStatus())
+}
+
+func TestIsOnDefaultBranch(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   GitStatus
+		expected bool
+	}{
+		{"main without known default", GitStatus{Branch: "main"}, true},
+		{"master without known default", GitStatus{Branch: "master"}, true},
+		{"feature without known default", GitStatus{Branch: "feature"}, false},
+		{"detected default", GitStatus{Branch: "trunk", DefaultBranch: "trunk"}, true},
+		{"other branch than detected default", GitStatus{Branch: "master", DefaultBranch: "trunk"}, false},
+		{"detached", GitStatus{Branch: "DETACHED", IsDetached: true, DefaultBranch: "main"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.status.IsOnDefaultBranch())
+		})
+	}
+}
+
+func TestGitStatusFormatDefaultBranchColor(t *testing.T) {
+	color.NoColor = false // Enable colors
+
+	status := GitStatus{Branch: "develop", DefaultBranch: "develop", HasRemote: true}
+	assert.Contains(t, status.Format(), grayColor("develop"), "the default branch is gray")
+
+	status = GitStatus{Branch: "main", DefaultBranch: "develop", HasRemote: true}
+	assert.Contains(t, status.Format(), yellowColor("main"), "other branches are yellow, even main")
+}
+
 // Test yellow brackets for non-standard status.
 func TestGitStatusFormatYellowBracketsNonStandard(t *testing.T) {
 	color.NoColor = false // Enable colors
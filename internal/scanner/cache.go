@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheVersion is bumped whenever the cache file layout changes; files written
+// with another version are ignored and replaced after the next full walk.
+const cacheVersion = 1
+
+// racyWindow is how long before a scan a directory must have last changed for
+// its listing to be cached. Changes made within the same mtime tick as the
+// listing would otherwise go unnoticed.
+const racyWindow = 2 * time.Second
+
+var errCacheMismatch = errors.New("cache file does not match")
+
+// cacheFile is the on-disk scan cache of a single scan root.
+type cacheFile struct {
+	Version     int                   `json:"version"`
+	Root        string                `json:"root"`
+	Directories map[string]*cachedDir `json:"directories"` // Keyed by absolute directory path
+}
+
+// cachedDir is what a scan learned about a directory the last time its mtime was seen.
+// Exclusion and ignore rules are not cached; they are applied again on every scan.
+type cachedDir struct {
+	ModTime int64         `json:"mtime"`             // Modification time (Unix nanoseconds)
+	Repo    bool          `json:"repo,omitempty"`    // Whether the directory is a Git repository
+	Bare    bool          `json:"bare,omitempty"`    // Whether the repository is bare
+	Listed  bool          `json:"listed,omitempty"`  // Whether Entries were read (repositories usually are not walked into)
+	Entries []cachedEntry `json:"entries,omitempty"` // Subdirectories and symbolic links, in lexical order
+}
+
+// cachedEntry is a subdirectory or symbolic link found in a directory.
+type cachedEntry struct {
+	Name    string `json:"name"`
+	Symlink bool   `json:"symlink,omitempty"`
+}
+
+// DefaultCacheDir returns the directory scan caches are kept in:
+// $XDG_CACHE_HOME/gitree, falling back to the platform's user cache directory.
+func DefaultCacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "gitree"), nil
+	}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cannot determine cache directory: %w", err)
+	}
+
+	return filepath.Join(dir, "gitree"), nil
+}
+
+// cacheFilePath returns the cache file of a scan root within cacheDir.
+func cacheFilePath(cacheDir, root string) string {
+	sum := sha256.Sum256([]byte(root))
+
+	return filepath.Join(cacheDir, "scan-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// loadCache reads the cached directories of a scan root. A missing cache file
+// yields no directories and no error.
+func loadCache(file, root string) (map[string]*cachedDir, error) {
+	data, err := os.ReadFile(filepath.Clean(file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var cache cacheFile
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", file, err)
+	}
+	if cache.Version != cacheVersion {
+		return nil, fmt.Errorf("%s has version %d, want %d: %w", file, cache.Version, cacheVersion, errCacheMismatch)
+	}
+	if cache.Root != root {
+		return nil, fmt.Errorf("%s belongs to %s: %w", file, cache.Root, errCacheMismatch)
+	}
+
+	return cache.Directories, nil
+}
+
+// saveCache atomically replaces the cache file of a scan root.
+func saveCache(file, root string, dirs map[string]*cachedDir) error {
+	data, err := json.Marshal(&cacheFile{Version: cacheVersion, Root: root, Directories: dirs})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name()) // No-op once renamed
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+// cachedListing returns the cached state of a directory if its mtime is unchanged, or nil.
+func (s *scanner) cachedListing(path string, modTime time.Time) *cachedDir {
+	if entry, ok := s.cached[path]; ok && entry.ModTime == modTime.UnixNano() {
+		return entry
+	}
+
+	return nil
+}
+
+// remember records the state of a directory for the next scan. Directories
+// changed too recently to be trusted are left out and read again next time.
+func (s *scanner) remember(path string, modTime time.Time, entry *cachedDir) {
+	if s.opts.CacheDir == "" || !modTime.Before(s.startTime.Add(-racyWindow)) {
+		return
+	}
+
+	entry.ModTime = modTime.UnixNano()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fresh[path] = entry
+}
+
+// loadScanCache loads the cache of the scan root unless caching is off or a rescan was requested.
+func (s *scanner) loadScanCache() {
+	if s.opts.CacheDir == "" {
+		return
+	}
+
+	s.fresh = make(map[string]*cachedDir)
+	if s.opts.Rescan {
+		debugPrintf(s.opts.Debug, "Ignoring scan cache: rescan requested")
+
+		return
+	}
+
+	file := cacheFilePath(s.opts.CacheDir, s.rootPath)
+	cached, err := loadCache(file, s.rootPath)
+	if err != nil {
+		debugPrintf(s.opts.Debug, "Ignoring scan cache: %v", err)
+
+		return
+	}
+	debugPrintf(s.opts.Debug, "Loaded %d directories from scan cache %s", len(cached), file)
+	s.cached = cached
+}
+
+// saveScanCache writes the directories seen by the scan to the cache. Failing
+// to write the cache only costs speed on the next run, so errors are not fatal.
+func (s *scanner) saveScanCache() {
+	if s.opts.CacheDir == "" {
+		return
+	}
+
+	file := cacheFilePath(s.opts.CacheDir, s.rootPath)
+	if err := saveCache(file, s.rootPath, s.fresh); err != nil {
+		debugPrintf(s.opts.Debug, "Cannot write scan cache %s: %v", file, err)
+
+		return
+	}
+	debugPrintf(s.opts.Debug, "Saved %d directories to scan cache %s", len(s.fresh), file)
+}
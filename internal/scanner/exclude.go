@@ -0,0 +1,116 @@
+package scanner
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// DefaultExcludes lists directories skipped unless ScanOptions.NoDefaultExcludes is set.
+// They are large, tool-managed trees that practically never contain repositories of interest.
+//
+//nolint:gochecknoglobals // Read-only default pattern list
+var DefaultExcludes = []string{
+	"node_modules",
+	"bower_components",
+	"__pycache__",
+	".venv",
+	".tox",
+	".npm",
+	".Trash",
+}
+
+// DefaultHomeExcludes lists directories of the user's home directory skipped unless
+// ScanOptions.NoDefaultExcludes is set. Directories with these names elsewhere,
+// e.g. a project named Library, are walked.
+//
+//nolint:gochecknoglobals // Read-only default list
+var DefaultHomeExcludes = defaultHomeExcludes(runtime.GOOS)
+
+// defaultHomeExcludes returns the home directory excludes of an operating system.
+func defaultHomeExcludes(goos string) []string {
+	excludes := []string{".cache"}
+	if goos == "darwin" {
+		excludes = append(excludes, "Library") // Application data, managed by macOS
+	}
+
+	return excludes
+}
+
+// validatePatterns checks that every glob pattern is well-formed.
+func validatePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// matchPattern reports whether a directory matches a glob pattern. Patterns
+// containing a slash are matched against the path relative to the scan root,
+// all others against the directory name alone.
+func matchPattern(pattern, relPath, name string) bool {
+	target := name
+	if strings.Contains(pattern, "/") {
+		target = relPath
+	}
+	matched, _ := path.Match(pattern, target) // Patterns are validated up front
+
+	return matched
+}
+
+// matchAny returns the first pattern matching the directory, or "" if none does.
+func matchAny(patterns []string, relPath, name string) string {
+	for _, pattern := range patterns {
+		if matchPattern(pattern, relPath, name) {
+			return pattern
+		}
+	}
+
+	return ""
+}
+
+// excludeReason returns why a directory should not be walked, or "" to walk it.
+// Include patterns take precedence over excludes, defaults and the hidden switch.
+func (s *scanner) excludeReason(dirPath, name string) string {
+	relPath, err := filepath.Rel(s.rootPath, dirPath)
+	if err != nil {
+		return ""
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	if matchAny(s.opts.Include, relPath, name) != "" {
+		return ""
+	}
+	if pattern := matchAny(s.opts.Exclude, relPath, name); pattern != "" {
+		return fmt.Sprintf("excluded by pattern %q", pattern)
+	}
+	if !s.opts.NoDefaultExcludes {
+		if pattern := matchAny(DefaultExcludes, relPath, name); pattern != "" {
+			return fmt.Sprintf("excluded by default pattern %q", pattern)
+		}
+		if s.homeDir != "" && filepath.Dir(dirPath) == s.homeDir && slices.Contains(DefaultHomeExcludes, name) {
+			return fmt.Sprintf("excluded by default pattern %q", "~/"+name)
+		}
+	}
+	if s.opts.SkipHidden && strings.HasPrefix(name, ".") {
+		return "hidden directory"
+	}
+
+	return ""
+}
+
+// depth returns how many levels below the scan root a directory is (the root is 0).
+func (s *scanner) depth(dirPath string) int {
+	relPath, err := filepath.Rel(s.rootPath, dirPath)
+	if err != nil || relPath == "." {
+		return 0
+	}
+
+	return strings.Count(filepath.ToSlash(relPath), "/") + 1
+}
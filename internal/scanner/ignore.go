@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// ignoreRule is a single gitignore-style pattern together with where it came from.
+type ignoreRule struct {
+	pattern gitignore.Pattern
+	source  string // File the rule was read from
+	line    int    // 1-based line number within source
+	text    string // Pattern text as written in source
+}
+
+// String returns the rule location and text, e.g. "/src/app/.gitignore:3: build/".
+func (r *ignoreRule) String() string {
+	return fmt.Sprintf("%s:%d: %s", r.source, r.line, r.text)
+}
+
+// ignoreFrame holds the rules read in a single directory; they apply to everything below it.
+type ignoreFrame struct {
+	dir        string        // Directory the rules were read in
+	rules      []*ignoreRule // Rules in file order
+	isRepoRoot bool          // Whether dir is a repository root (rules of enclosing repositories stop here)
+}
+
+// splitPath splits an absolute path into components so patterns from different
+// directories can share the file system root as their common base.
+func splitPath(path string) []string {
+	return strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+}
+
+// isWithin reports whether path is dir itself or located below it.
+func isWithin(path, dir string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+}
+
+// readIgnoreRules reads gitignore-syntax rules from file and scopes them to dir.
+// A missing file yields no rules and no error.
+func readIgnoreRules(file, dir string) ([]*ignoreRule, error) {
+	f, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close() // Ignore close error on read-only file
+	}()
+
+	domain := splitPath(dir)
+	lineScanner := bufio.NewScanner(f)
+	var rules []*ignoreRule
+
+	for lineNo := 1; lineScanner.Scan(); lineNo++ {
+		text := strings.TrimRight(lineScanner.Text(), " \t\r")
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rules = append(rules, &ignoreRule{
+			pattern: gitignore.ParsePattern(text, domain),
+			source:  file,
+			line:    lineNo,
+			text:    text,
+		})
+	}
+
+	if err := lineScanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ignoreStack holds the frames of the directories enclosing the current walk position.
+type ignoreStack []*ignoreFrame
+
+// with returns the stack extended by frame, leaving st untouched so the
+// directories sharing it are unaffected. A nil frame returns st itself.
+func (st ignoreStack) with(frame *ignoreFrame) ignoreStack {
+	if frame == nil {
+		return st
+	}
+
+	return append(st[:len(st):len(st)], frame)
+}
+
+// insideRepository reports whether any frame belongs to a repository root.
+func (st ignoreStack) insideRepository() bool {
+	for _, frame := range st {
+		if frame.isRepoRoot {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ignoredBy returns the rule that excludes path, or nil if path is not ignored.
+// Like git, later and deeper rules take precedence, and a repository root frame
+// stops the search so a nested repository's rules are not combined with those
+// of the repository enclosing it.
+func (st ignoreStack) ignoredBy(path string) *ignoreRule {
+	parts := splitPath(path)
+
+	for i := len(st) - 1; i >= 0; i-- {
+		frame := st[i]
+		for j := len(frame.rules) - 1; j >= 0; j-- {
+			switch frame.rules[j].pattern.Match(parts, true) {
+			case gitignore.Exclude:
+				return frame.rules[j]
+			case gitignore.Include:
+				return nil
+			case gitignore.NoMatch:
+			}
+		}
+
+		if frame.isRepoRoot {
+			break
+		}
+	}
+
+	return nil
+}
+
+// readIgnoreFrame reads the given ignore files into a frame active below dir.
+// It returns nil when there are no rules, except for repository roots, which
+// always get a frame so nested scopes reset correctly.
+func (s *scanner) readIgnoreFrame(dir string, isRepoRoot bool, files ...string) *ignoreFrame {
+	frame := &ignoreFrame{dir: dir, isRepoRoot: isRepoRoot}
+
+	for _, file := range files {
+		rules, err := readIgnoreRules(file, dir)
+		if err != nil {
+			debugPrintf(s.opts.Debug, "Cannot read ignore file %s: %v", file, err)
+
+			continue
+		}
+		if len(rules) > 0 {
+			debugPrintf(s.opts.Debug, "Loaded %d rules from %s", len(rules), file)
+		}
+		frame.rules = append(frame.rules, rules...)
+	}
+
+	if len(frame.rules) == 0 && !isRepoRoot {
+		return nil
+	}
+
+	return frame
+}
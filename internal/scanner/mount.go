@@ -0,0 +1,74 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+)
+
+// specialFileSystems lists pseudo and network file system types skipped when
+// ScanOptions.SkipSpecialFS is set. Names follow fileSystemType on each platform.
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var specialFileSystems = map[string]bool{
+	// Pseudo file systems (kernel and process state, never hold repositories)
+	"proc": true, "sysfs": true, "devpts": true, "devfs": true, "cgroup": true, "cgroup2": true,
+	"debugfs": true, "tracefs": true, "securityfs": true, "pstore": true, "bpf": true,
+	"configfs": true, "fusectl": true, "mqueue": true, "hugetlbfs": true, "binfmt_misc": true,
+	"nsfs": true, "autofs": true,
+	// Network and user-space file systems (slow to walk, often mounted from elsewhere)
+	"nfs": true, "smbfs": true, "smb2": true, "cifs": true, "afpfs": true, "webdav": true,
+	"9p": true, "afs": true, "ceph": true, "fuse": true, "macfuse": true, "osxfuse": true,
+}
+
+// mountReason returns why a directory on another file system should not be walked,
+// or "" to walk it. Only directories whose device differs from the scan root are
+// inspected, and file system types are looked up once per device.
+func (s *scanner) mountReason(path string) (string, error) {
+	if !s.opts.OneFileSystem && !s.opts.SkipSpecialFS {
+		return "", nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	id, ok := fileIdentity(info)
+	if !ok {
+		return "", nil // Device unknown, nothing to compare
+	}
+
+	dev := id.dev
+	if dev == s.rootDev {
+		return "", nil
+	}
+
+	if s.opts.OneFileSystem {
+		return "different file system", nil
+	}
+
+	s.mu.Lock()
+	fsType, cached := s.fsTypes[dev]
+	s.mu.Unlock()
+	if !cached {
+		fsType, err = fileSystemType(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot determine file system type: %w", err)
+		}
+		s.mu.Lock()
+		s.fsTypes[dev] = fsType
+		s.mu.Unlock()
+	}
+
+	if specialFileSystems[fsType] {
+		return fsType + " file system", nil
+	}
+
+	return "", nil
+}
+
+// rootDevice returns the device the scan root lives on.
+func rootDevice(info os.FileInfo) uint64 {
+	id, _ := fileIdentity(info)
+
+	return id.dev
+}
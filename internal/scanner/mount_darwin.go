@@ -0,0 +1,34 @@
+package scanner
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the (device, inode) pair of a file.
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(uint32(stat.Dev)), ino: stat.Ino}, true //nolint:gosec // Device numbers are opaque identifiers
+}
+
+// fileSystemType returns the name of the file system path lives on.
+func fileSystemType(path string) (string, error) {
+	var statfs syscall.Statfs_t
+	if err := syscall.Statfs(path, &statfs); err != nil {
+		return "", err
+	}
+
+	name := make([]byte, 0, len(statfs.Fstypename))
+	for _, c := range statfs.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+
+	return string(name), nil
+}
@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// linuxFileSystemMagic maps statfs(2) f_type magic numbers to file system names.
+//
+//nolint:gochecknoglobals // Read-only lookup table
+var linuxFileSystemMagic = map[uint32]string{
+	0x9fa0:     "proc",
+	0x62656572: "sysfs",
+	0x1cd1:     "devpts",
+	0x27e0eb:   "cgroup",
+	0x63677270: "cgroup2",
+	0x64626720: "debugfs",
+	0x74726163: "tracefs",
+	0x73636673: "securityfs",
+	0x6165676c: "pstore",
+	0xcafe4a11: "bpf",
+	0x62656570: "configfs",
+	0x65735543: "fusectl",
+	0x19800202: "mqueue",
+	0x958458f6: "hugetlbfs",
+	0x42494e4d: "binfmt_misc",
+	0x6e736673: "nsfs",
+	0x0187:     "autofs",
+	0x6969:     "nfs",
+	0x517b:     "smbfs",
+	0xfe534d42: "smb2",
+	0xff534d42: "cifs",
+	0x01021997: "9p",
+	0x5346414f: "afs",
+	0x00c36400: "ceph",
+	0x65735546: "fuse",
+}
+
+// fileIdentity returns the (device, inode) pair of a file.
+func fileIdentity(info os.FileInfo) (fileID, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+
+	return fileID{dev: uint64(stat.Dev), ino: stat.Ino}, true //nolint:unconvert // uint32 on mips
+}
+
+// fileSystemType returns the name of the file system path lives on.
+// Unknown types are reported by their magic number.
+func fileSystemType(path string) (string, error) {
+	var statfs syscall.Statfs_t
+	if err := syscall.Statfs(path, &statfs); err != nil {
+		return "", err
+	}
+
+	// f_type is 32 bits wide, but signed on some architectures and widened on others
+	magic := uint32(statfs.Type) //nolint:gosec,unconvert // int32 on 386, arm and mips, uint32 on s390x
+	if name, ok := linuxFileSystemMagic[magic]; ok {
+		return name, nil
+	}
+
+	return fmt.Sprintf("0x%x", magic), nil
+}
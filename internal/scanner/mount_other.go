@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package scanner
+
+import (
+	"os"
+)
+
+// fileIdentity reports that device and inode numbers are not available: mount
+// detection is disabled, and the walker identifies directories by path instead.
+func fileIdentity(os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
+
+// fileSystemType is never called, as no directory is known to be on another device.
+func fileSystemType(string) (string, error) {
+	return "", nil
+}
@@ -4,19 +4,164 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
-	"syscall"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/andreygrechin/gitree/internal/models"
 )
 
+// FollowSymlinks controls whether symbolic links to directories are walked into.
+type FollowSymlinks string
+
+const (
+	// FollowSymlinksNever does not walk into symlinked directories (default).
+	FollowSymlinksNever FollowSymlinks = "never"
+	// FollowSymlinksWithinRoot walks into symlinked directories whose target is inside the scan root.
+	FollowSymlinksWithinRoot FollowSymlinks = "within-root"
+	// FollowSymlinksAlways walks into all symlinked directories.
+	FollowSymlinksAlways FollowSymlinks = "always"
+)
+
+var errInvalidFollowSymlinks = errors.New("invalid symlink policy")
+
+// ParseFollowSymlinks converts a policy name into a FollowSymlinks value.
+func ParseFollowSymlinks(value string) (FollowSymlinks, error) {
+	switch policy := FollowSymlinks(value); policy {
+	case FollowSymlinksNever, FollowSymlinksWithinRoot, FollowSymlinksAlways:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %q (must be %s, %s or %s)", errInvalidFollowSymlinks,
+			value, FollowSymlinksNever, FollowSymlinksWithinRoot, FollowSymlinksAlways)
+	}
+}
+
 // ScanOptions configures the directory scanning behavior.
 type ScanOptions struct {
-	RootPath string // Root directory to start scanning from
-	Debug    bool   // Enable debug output for scanning operations
+	RootPath          string         // Root directory to start scanning from
+	Debug             bool           // Enable debug output for scanning operations
+	Nested            bool           // Keep descending into repositories to find nested ones (only one level into what they ignore)
+	MaxDepth          int            // Maximum directory depth below RootPath to descend into (0 = unlimited)
+	Exclude           []string       // Glob patterns of directories to skip (name, or path relative to RootPath if it contains "/")
+	Include           []string       // Glob patterns of directories to walk even if excluded, default-excluded or hidden
+	SkipHidden        bool           // Skip directories whose name starts with "."
+	NoDefaultExcludes bool           // Do not apply DefaultExcludes
+	OneFileSystem     bool           // Do not descend into directories on a different file system than RootPath
+	SkipSpecialFS     bool           // Do not descend into pseudo (proc, sysfs, ...) or network (NFS, SMB, FUSE, ...) mounts
+	FollowSymlinks    FollowSymlinks // Symlinked directory policy (empty = FollowSymlinksNever)
+	Concurrency       int            // Directories read in parallel (0 = DefaultConcurrency, 1 = sequential)
+	CacheDir          string         // Directory of the persistent scan cache (empty = no cache)
+	Rescan            bool           // Ignore the scan cache and walk every directory (the cache is still updated)
+}
+
+// GitDirInfo describes where a working copy keeps its Git metadata.
+type GitDirInfo struct {
+	GitDir    string                // Resolved Git directory
+	CommonDir string                // Shared Git directory (differs from GitDir only for linked worktrees)
+	Kind      models.RepositoryKind // Main checkout, linked worktree or submodule
+}
+
+const gitDirPrefix = "gitdir:"
+
+var errInvalidGitFile = errors.New("invalid .git file")
+
+// ResolveGitDir inspects the .git entry of a working copy and resolves its Git directory.
+// A .git directory is returned as-is; a .git pointer file ("gitdir: <path>") written by
+// "git worktree add" or "git submodule" is followed to the real Git directory, and its
+// commondir (if any) is resolved to the repository that owns the objects and refs.
+func ResolveGitDir(path string) (*GitDirInfo, error) {
+	dotGit := filepath.Join(path, ".git")
+
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &GitDirInfo{GitDir: dotGit, CommonDir: dotGit, Kind: models.RepositoryKindMain}, nil
+	}
+
+	gitDir, err := readGitFile(dotGit)
+	if err != nil {
+		return nil, err
+	}
+
+	gitDirStat, err := os.Stat(gitDir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access gitdir %s: %w", gitDir, err)
+	}
+	if !gitDirStat.IsDir() {
+		return nil, fmt.Errorf("gitdir %s is not a directory: %w", gitDir, errInvalidGitFile)
+	}
+
+	result := &GitDirInfo{GitDir: gitDir, CommonDir: gitDir, Kind: models.RepositoryKindMain}
+
+	// Linked worktrees record the location of the main repository in "commondir"
+	commonDir, err := readCommonDir(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	if commonDir != "" {
+		result.CommonDir = commonDir
+		result.Kind = models.RepositoryKindWorktree
+
+		return result, nil
+	}
+
+	// Absorbed submodules keep their Git directory under <superproject>/.git/modules/
+	if strings.Contains(filepath.ToSlash(gitDir), "/.git/modules/") {
+		result.Kind = models.RepositoryKindSubmodule
+	}
+
+	return result, nil
+}
+
+// readGitFile parses a .git pointer file and returns the absolute Git directory it refers to.
+func readGitFile(dotGit string) (string, error) {
+	data, err := os.ReadFile(filepath.Clean(dotGit))
+	if err != nil {
+		return "", err
+	}
+
+	line, _, _ := strings.Cut(string(data), "\n")
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, gitDirPrefix) {
+		return "", fmt.Errorf("%s has no %q prefix: %w", dotGit, gitDirPrefix, errInvalidGitFile)
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, gitDirPrefix))
+	if gitDir == "" {
+		return "", fmt.Errorf("%s has an empty gitdir: %w", dotGit, errInvalidGitFile)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(dotGit), gitDir)
+	}
+
+	return filepath.Clean(gitDir), nil
+}
+
+// readCommonDir returns the resolved commondir of a Git directory, or "" if it has none.
+func readCommonDir(gitDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+
+		return "", err
+	}
+
+	commonDir := strings.TrimSpace(string(data))
+	if commonDir == "" {
+		return "", nil
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+
+	return filepath.Clean(commonDir), nil
 }
 
 // IsGitRepository checks if a directory is a Git repository
@@ -24,10 +169,9 @@ type ScanOptions struct {
 // - isRepo: true if directory contains a Git repository
 // - isBare: true if it's a bare repository.
 func IsGitRepository(path string) (isRepo, isBare bool) {
-	// Check for regular repository (.git directory)
-	gitDir := filepath.Join(path, ".git")
-	if info, err := os.Stat(gitDir); err == nil && info.IsDir() {
-		return true, false // regular repo
+	// Check for regular repository (.git directory or gitdir pointer file)
+	if _, err := ResolveGitDir(path); err == nil {
+		return true, false // regular repo, linked worktree or submodule
 	}
 
 	// Check for bare repository (HEAD, refs/, objects/ in root)
@@ -68,21 +212,101 @@ func debugPrintf(debug bool, format string, args ...any) {
 }
 
 // scanner holds state during directory traversal.
+// Fields below mu are shared by the walker's workers and guarded by it.
 type scanner struct {
 	rootPath     string
-	opts         ScanOptions // Store full options instead of just rootPath
+	opts         ScanOptions               // Store full options instead of just rootPath
+	realRootPath string                    // Root path with symlinks resolved
+	rootDev      uint64                    // Device of the scan root
+	homeDir      string                    // User's home directory, for DefaultHomeExcludes ("" if unknown)
+	stream       chan<- *models.Repository // Receives repositories as they are found (ScanStream only)
+	startTime    time.Time
+	cached       map[string]*cachedDir // Directories as seen by the previous scan (read-only while walking)
 	repositories []*models.Repository
-	errors       []error
-	visited      map[uint64]bool // Track visited inodes to prevent symlink loops
-	dirCount     int
+
+	mu            sync.Mutex
+	found         []*models.Repository // Repositories in the order workers found them
+	visited       map[fileID]struct{}  // Directories claimed by the walk, reached through any path
+	errors        []error
+	dirCount      int
+	skipCount     int                   // Directories skipped by exclusion rules
+	fsTypes       map[uint64]string     // File system type per device, looked up once
+	mountsSkipped []string              // Mount points not descended into
+	fresh         map[string]*cachedDir // Directories as seen by this scan, saved for the next one
+	cacheHits     int                   // Directories listed from the cache
+}
+
+// TreeIgnoreFile is the name of the per-directory file (gitignore syntax) listing
+// subtrees gitree never walks into. Its rules apply to the directory's subtree.
+const TreeIgnoreFile = ".gitreeignore"
+
+// fileID identifies a directory independently of the path it was reached by.
+type fileID struct {
+	dev  uint64
+	ino  uint64
+	path string // Path with symbolic links resolved, on systems without inode numbers
 }
 
 var errScanResultValidation = errors.New("scan result validation error")
 
 // Scan recursively scans a directory tree for Git repositories.
 func Scan(ctx context.Context, opts ScanOptions) (*models.ScanResult, error) {
-	startTime := time.Now()
+	s, err := newScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx)
+}
+
+// Stream is a scan running in the background, started by ScanStream.
+type Stream struct {
+	// Repositories receives each repository as soon as it is found, in no particular
+	// order, and is closed when the walk ends. It must be drained (or the context
+	// canceled) for the scan to complete. Each directory is walked once, so a repository
+	// reached through several paths is sent once, under the path walked first.
+	Repositories <-chan *models.Repository
+
+	done   chan struct{}
+	result *models.ScanResult
+	err    error
+}
+
+// Result waits for the scan to finish and returns what Scan would have returned.
+func (st *Stream) Result() (*models.ScanResult, error) {
+	<-st.done
+
+	return st.result, st.err
+}
+
+// streamBuffer is the number of found repositories buffered for a slow consumer.
+const streamBuffer = 64
 
+// ScanStream starts scanning like Scan, but returns immediately so callers can
+// process repositories while the walk is still running. Invalid options are
+// reported right away; walk errors are returned by Stream.Result.
+func ScanStream(ctx context.Context, opts ScanOptions) (*Stream, error) {
+	s, err := newScanner(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	repos := make(chan *models.Repository, streamBuffer)
+	s.stream = repos
+	st := &Stream{Repositories: repos, done: make(chan struct{})}
+
+	go func() {
+		defer close(st.done)
+
+		st.result, st.err = s.run(ctx)
+		close(repos)
+	}()
+
+	return st, nil
+}
+
+// newScanner validates the options and prepares a scanner for them.
+func newScanner(opts ScanOptions) (*scanner, error) {
 	// Validate root path exists
 	info, err := os.Stat(opts.RootPath)
 	if err != nil {
@@ -98,168 +322,99 @@ func Scan(ctx context.Context, opts ScanOptions) (*models.ScanResult, error) {
 		return nil, fmt.Errorf("cannot get absolute path: %w: %w", errScanResultValidation, err)
 	}
 
-	s := &scanner{
-		rootPath:     absPath,
-		opts:         opts,
-		repositories: make([]*models.Repository, 0),
-		errors:       make([]error, 0),
-		visited:      make(map[uint64]bool),
-	}
-
-	// Walk directory tree
-	// Create closure to pass context to walkFunc
-	walkFn := func(path string, d fs.DirEntry, err error) error {
-		return s.walkFunc(ctx, path, d, err)
+	// Validate exclusion options
+	if opts.MaxDepth < 0 {
+		return nil, fmt.Errorf("max depth cannot be negative: %d: %w", opts.MaxDepth, errScanResultValidation)
 	}
-	err = filepath.WalkDir(absPath, walkFn)
-	if err != nil && !errors.Is(err, context.Canceled) {
-		// If it's not a context cancellation, it's a fatal error
-		return nil, fmt.Errorf("error walking directory tree: %w", err)
+	if opts.Concurrency < 0 {
+		return nil, fmt.Errorf("concurrency cannot be negative: %d: %w", opts.Concurrency, errScanResultValidation)
 	}
-
-	// Build tree structure from flat repository list
-	tree := s.buildTree()
-
-	result := &models.ScanResult{
-		RootPath:     absPath,
-		Repositories: s.repositories,
-		Tree:         tree,
-		TotalScanned: s.dirCount,
-		TotalRepos:   len(s.repositories),
-		Errors:       s.errors,
-		Duration:     time.Since(startTime),
-	}
-
-	return result, nil
-}
-
-var errPermissionDenied = errors.New("permission denied")
-
-// walkFunc is called for each file/directory during traversal.
-func (s *scanner) walkFunc(ctx context.Context, path string, d fs.DirEntry, err error) error {
-	// Check for context cancellation
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-	}
-
-	// Handle permission errors (non-fatal)
-	if err != nil {
-		if os.IsPermission(err) {
-			debugPrintf(s.opts.Debug, "Skipping %s: permission denied", path)
-			s.errors = append(s.errors, fmt.Errorf("permission denied: %s: %w", path, errPermissionDenied))
-
-			return fs.SkipDir // Skip this directory but continue scanning
+	for _, patterns := range [][]string{opts.Exclude, opts.Include} {
+		if err := validatePatterns(patterns); err != nil {
+			return nil, fmt.Errorf("%w: %w", errScanResultValidation, err)
 		}
-
-		return err
 	}
 
-	// Only process directories
-	if !d.IsDir() {
-		return nil
+	if opts.FollowSymlinks != "" {
+		if _, err := ParseFollowSymlinks(string(opts.FollowSymlinks)); err != nil {
+			return nil, fmt.Errorf("%w: %w", errScanResultValidation, err)
+		}
 	}
 
-	debugPrintf(s.opts.Debug, "Entering directory: %s", path)
-
-	s.dirCount++
-
-	// Check for symlink loops using inode tracking
-	shouldVisit, isSymlink, err := s.shouldVisit(path)
+	realRootPath, err := filepath.EvalSymlinks(absPath)
 	if err != nil {
-		debugPrintf(s.opts.Debug, "Skipping %s: %v", path, err)
-		s.errors = append(s.errors, fmt.Errorf("error checking path %s: %w", path, err))
-
-		return fs.SkipDir
+		return nil, fmt.Errorf("cannot resolve root path: %w: %w", errScanResultValidation, err)
 	}
-	if !shouldVisit {
-		debugPrintf(s.opts.Debug, "Skipping %s: already visited (symlink loop)", path)
 
-		return fs.SkipDir // Already visited or symlink loop
+	homeDir, err := os.UserHomeDir()
+	if err == nil {
+		homeDir = filepath.Clean(homeDir)
+	} else {
+		homeDir = "" // Home directory excludes do not apply
 	}
 
-	// Check if this directory is a Git repository
-	isRepo, isBare := IsGitRepository(path)
-	if isRepo {
-		repoType := "regular"
-		if isBare {
-			repoType = "bare"
-		}
-		debugPrintf(s.opts.Debug, "Found git repository: %s (%s)", path, repoType)
-
-		repo := &models.Repository{
-			Path:      path,
-			Name:      filepath.Base(path),
-			IsBare:    isBare,
-			IsSymlink: isSymlink,
-		}
-
-		s.repositories = append(s.repositories, repo)
-
-		// Skip traversing into repository contents (FR-018)
-		// We found a repo, so we don't need to look inside it for more repos
-		debugPrintf(s.opts.Debug, "Skipping %s: inside git repository", path)
-
-		return fs.SkipDir
+	s := &scanner{
+		rootPath:     absPath,
+		realRootPath: realRootPath,
+		opts:         opts,
+		homeDir:      homeDir,
+		errors:       make([]error, 0),
+		rootDev:      rootDevice(info),
+		fsTypes:      make(map[uint64]string),
+		visited:      make(map[fileID]struct{}),
 	}
 
-	return nil
+	return s, nil
 }
 
-// shouldVisit checks if a path should be visited (handles symlink loops)
-// Returns (shouldVisit, isSymlink, error).
-func (s *scanner) shouldVisit(path string) (shouldVisit, isSymlink bool, err error) {
-	// Get file info without following symlinks
-	info, err := os.Lstat(path)
-	if err != nil {
-		return false, false, err
-	}
+// run walks the tree and assembles the scan result.
+func (s *scanner) run(ctx context.Context) (*models.ScanResult, error) {
+	s.startTime = time.Now()
+	absPath := s.rootPath
+	opts := s.opts
 
-	// Check if it's a symlink
-	isSymlink = info.Mode()&os.ModeSymlink != 0
-	var actualPath string
+	s.loadScanCache()
 
-	if isSymlink {
-		// Follow symlink to get real path
-		actualPath, err = filepath.EvalSymlinks(path)
-		if err != nil {
-			// Broken symlink
-			return false, false, err
-		}
-
-		// Get info of the target
-		info, err = os.Stat(actualPath)
-		if err != nil {
-			return false, false, err
-		}
-
-		// If symlink target is not a directory, skip
-		if !info.IsDir() {
-			return false, false, nil
-		}
+	// Walk directory tree
+	err := s.walk(ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		// If it's not a context cancellation, it's a fatal error
+		return nil, fmt.Errorf("error walking directory tree: %w", err)
 	}
+	s.finish()
 
-	// Get inode to track visited paths
-	stat, ok := info.Sys().(*syscall.Stat_t)
-	if !ok {
-		// Can't get inode (might be on Windows), just visit it
-		return true, isSymlink, nil
+	// Only a complete walk knows every directory below the root
+	if err == nil {
+		s.saveScanCache()
 	}
 
-	inode := stat.Ino
+	// Build tree structure from flat repository list
+	tree := s.buildTree()
 
-	// Check if already visited
-	if s.visited[inode] {
-		return false, isSymlink, nil // Already visited, skip
+	result := &models.ScanResult{
+		RootPath:      absPath,
+		Repositories:  s.repositories,
+		Tree:          tree,
+		TotalScanned:  s.dirCount,
+		TotalSkipped:  s.skipCount,
+		SkippedMounts: s.mountsSkipped,
+		TotalRepos:    len(s.repositories),
+		Errors:        s.errors,
+		Duration:      time.Since(s.startTime),
 	}
 
-	// Mark as visited
-	s.visited[inode] = true
+	debugPrintf(opts.Debug,
+		"Scan of %s complete: %d directories scanned (%d listed from cache), %d skipped, %d repositories found in %s",
+		absPath, result.TotalScanned, s.cacheHits, result.TotalSkipped, result.TotalRepos, result.Duration)
+	if len(result.SkippedMounts) > 0 {
+		debugPrintf(opts.Debug, "Mount points not descended into: %s", strings.Join(result.SkippedMounts, ", "))
+	}
 
-	return true, isSymlink, nil
+	return result, nil
 }
 
+var errPermissionDenied = errors.New("permission denied")
+
 // buildTree creates a TreeNode structure from flat repository list.
 func (s *scanner) buildTree() *models.TreeNode {
 	if len(s.repositories) == 0 {
@@ -2,10 +2,16 @@ package scanner
 
 import (
 	"context"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/andreygrechin/gitree/internal/models"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -352,3 +358,806 @@ func TestIsGitRepository_BareRepoPatterns(t *testing.T) {
 		})
 	}
 }
+
+// createLinkedCheckout creates a working copy whose .git is a pointer file to gitDir.
+// When commonDir is non-empty, gitDir is given a commondir file like a linked worktree.
+func createLinkedCheckout(t *testing.T, path, gitDir, commonDir string) {
+	t.Helper()
+
+	err := os.MkdirAll(path, 0o750)
+	require.NoError(t, err)
+	err = os.MkdirAll(gitDir, 0o750)
+	require.NoError(t, err)
+	err = os.WriteFile(filepath.Join(gitDir, "HEAD"), []byte("ref: refs/heads/main\n"), 0o600)
+	require.NoError(t, err)
+
+	if commonDir != "" {
+		err = os.WriteFile(filepath.Join(gitDir, "commondir"), []byte(commonDir+"\n"), 0o600)
+		require.NoError(t, err)
+	}
+
+	err = os.WriteFile(filepath.Join(path, ".git"), []byte("gitdir: "+gitDir+"\n"), 0o600)
+	require.NoError(t, err)
+}
+
+// Test ResolveGitDir() classifying main checkouts, linked worktrees and submodules.
+func TestResolveGitDir_Kinds(t *testing.T) {
+	tempDir := t.TempDir()
+
+	mainPath := filepath.Join(tempDir, "main")
+	createTestRepo(t, mainPath, false)
+	mainGitDir := filepath.Join(mainPath, ".git")
+
+	worktreePath := filepath.Join(tempDir, "feature")
+	worktreeGitDir := filepath.Join(mainGitDir, "worktrees", "feature")
+	createLinkedCheckout(t, worktreePath, worktreeGitDir, "../..")
+
+	submodulePath := filepath.Join(mainPath, "libs", "dep")
+	submoduleGitDir := filepath.Join(mainGitDir, "modules", "libs", "dep")
+	createLinkedCheckout(t, submodulePath, submoduleGitDir, "")
+
+	separatePath := filepath.Join(tempDir, "separate")
+	separateGitDir := filepath.Join(tempDir, "separate-gitdir")
+	createLinkedCheckout(t, separatePath, separateGitDir, "")
+
+	tests := []struct {
+		name          string
+		path          string
+		wantKind      models.RepositoryKind
+		wantGitDir    string
+		wantCommonDir string
+	}{
+		{"main checkout", mainPath, models.RepositoryKindMain, mainGitDir, mainGitDir},
+		{"linked worktree", worktreePath, models.RepositoryKindWorktree, worktreeGitDir, mainGitDir},
+		{"submodule", submodulePath, models.RepositoryKindSubmodule, submoduleGitDir, submoduleGitDir},
+		{"separate git dir", separatePath, models.RepositoryKindMain, separateGitDir, separateGitDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ResolveGitDir(tt.path)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantKind, info.Kind)
+			assert.Equal(t, tt.wantGitDir, info.GitDir)
+			assert.Equal(t, tt.wantCommonDir, info.CommonDir)
+		})
+	}
+}
+
+// Test ResolveGitDir() resolving a relative gitdir pointer.
+func TestResolveGitDir_RelativePointer(t *testing.T) {
+	tempDir := t.TempDir()
+
+	submodulePath := filepath.Join(tempDir, "super", "dep")
+	submoduleGitDir := filepath.Join(tempDir, "super", ".git", "modules", "dep")
+	createLinkedCheckout(t, submodulePath, submoduleGitDir, "")
+	err := os.WriteFile(filepath.Join(submodulePath, ".git"), []byte("gitdir: ../.git/modules/dep\n"), 0o600)
+	require.NoError(t, err)
+
+	info, err := ResolveGitDir(submodulePath)
+
+	require.NoError(t, err)
+	assert.Equal(t, submoduleGitDir, info.GitDir)
+	assert.Equal(t, models.RepositoryKindSubmodule, info.Kind)
+}
+
+// Test ResolveGitDir() rejecting malformed or dangling pointer files.
+func TestResolveGitDir_InvalidPointer(t *testing.T) {
+	tempDir := t.TempDir()
+
+	malformedPath := filepath.Join(tempDir, "malformed")
+	require.NoError(t, os.MkdirAll(malformedPath, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(malformedPath, ".git"), []byte("not a pointer\n"), 0o600))
+
+	danglingPath := filepath.Join(tempDir, "dangling")
+	require.NoError(t, os.MkdirAll(danglingPath, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(danglingPath, ".git"), []byte("gitdir: /nonexistent/gitdir\n"), 0o600))
+
+	_, err := ResolveGitDir(malformedPath)
+	require.ErrorIs(t, err, errInvalidGitFile)
+
+	_, err = ResolveGitDir(danglingPath)
+	require.Error(t, err)
+
+	isRepo, _ := IsGitRepository(danglingPath)
+	assert.False(t, isRepo, "Dangling gitdir pointer should not be detected as repository")
+}
+
+// Test Scan() finding linked worktrees and recording their kind.
+func TestScan_FindsLinkedWorktrees(t *testing.T) {
+	tempDir := t.TempDir()
+
+	mainPath := filepath.Join(tempDir, "project")
+	createTestRepo(t, mainPath, false)
+	worktreeGitDir := filepath.Join(mainPath, ".git", "worktrees", "project-feature")
+	createLinkedCheckout(t, filepath.Join(tempDir, "project-feature"), worktreeGitDir, "../..")
+
+	ctx := context.Background()
+	result, err := Scan(ctx, ScanOptions{RootPath: tempDir})
+
+	require.NoError(t, err)
+	require.Len(t, result.Repositories, 2)
+
+	kinds := make(map[string]models.RepositoryKind)
+	for _, repo := range result.Repositories {
+		kinds[repo.Name] = repo.Kind
+	}
+	assert.Equal(t, models.RepositoryKindMain, kinds["project"])
+	assert.Equal(t, models.RepositoryKindWorktree, kinds["project-feature"])
+}
+
+// Test Scan() in nested mode finding repositories inside other repositories.
+func TestScan_NestedModeFindsNestedRepos(t *testing.T) {
+	tempDir := t.TempDir()
+
+	parentPath := filepath.Join(tempDir, "parent-repo")
+	createTestRepo(t, parentPath, false)
+	createTestRepo(t, filepath.Join(parentPath, "third_party", "vendored"), false)
+	createTestRepo(t, filepath.Join(parentPath, "third_party", "vendored", "deep"), false)
+
+	ctx := context.Background()
+	result, err := Scan(ctx, ScanOptions{RootPath: tempDir, Nested: true})
+
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		names = append(names, repo.Name)
+	}
+	assert.ElementsMatch(t, []string{"parent-repo", "vendored", "deep"}, names)
+}
+
+// Test Scan() in nested mode honoring the enclosing repository's .gitignore.
+func TestScan_NestedModeHonorsGitignore(t *testing.T) {
+	tempDir := t.TempDir()
+
+	parentPath := filepath.Join(tempDir, "parent-repo")
+	createTestRepo(t, parentPath, false)
+	err := os.WriteFile(filepath.Join(parentPath, ".gitignore"), []byte("build/\nthird_party/\n"), 0o600)
+	require.NoError(t, err)
+
+	// Repo two levels below an ignored directory that is not itself a repo: skipped
+	createTestRepo(t, filepath.Join(parentPath, "build", "cache", "tool"), false)
+	// Ignored directory that is a repository itself: still found
+	createTestRepo(t, filepath.Join(parentPath, "third_party"), false)
+	// Repo in a non-ignored directory: found
+	createTestRepo(t, filepath.Join(parentPath, "libs", "lib-a"), false)
+
+	ctx := context.Background()
+	result, err := Scan(ctx, ScanOptions{RootPath: tempDir, Nested: true})
+
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		names = append(names, repo.Name)
+	}
+	assert.ElementsMatch(t, []string{"parent-repo", "third_party", "lib-a"}, names)
+}
+
+// Test Scan() in nested mode finding clones directly inside ignored directories.
+func TestScan_NestedModeFindsClonesInIgnoredDirectories(t *testing.T) {
+	tempDir := t.TempDir()
+
+	monorepoPath := filepath.Join(tempDir, "monorepo")
+	createTestRepo(t, monorepoPath, false)
+	err := os.WriteFile(filepath.Join(monorepoPath, ".gitignore"), []byte("third_party/\nvendor/\n"), 0o600)
+	require.NoError(t, err)
+
+	createTestRepo(t, filepath.Join(monorepoPath, "third_party", "foo"), false)
+	createTestRepo(t, filepath.Join(monorepoPath, "third_party", "bar"), false)
+	createTestRepo(t, filepath.Join(monorepoPath, "vendor", "inner"), false)
+	// Not a clone: its contents are not walked
+	require.NoError(t, os.MkdirAll(filepath.Join(monorepoPath, "third_party", "downloads", "cache"), 0o755))
+
+	result, err := Scan(context.Background(), ScanOptions{RootPath: tempDir, Nested: true})
+
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		names = append(names, repo.Name)
+	}
+	assert.ElementsMatch(t, []string{"monorepo", "foo", "bar", "inner"}, names)
+	assert.Equal(t, 1, result.TotalSkipped, "third_party/downloads")
+}
+
+// Test Scan() honoring MaxDepth.
+func TestScan_MaxDepth(t *testing.T) {
+	tempDir := t.TempDir()
+
+	createTestRepo(t, filepath.Join(tempDir, "repo1"), false)
+	createTestRepo(t, filepath.Join(tempDir, "a", "repo2"), false)
+	createTestRepo(t, filepath.Join(tempDir, "a", "b", "repo3"), false)
+
+	tests := []struct {
+		maxDepth int
+		want     int
+	}{
+		{maxDepth: 0, want: 3},
+		{maxDepth: 1, want: 1},
+		{maxDepth: 2, want: 2},
+		{maxDepth: 3, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("max depth %d", tt.maxDepth), func(t *testing.T) {
+			result, err := Scan(context.Background(), ScanOptions{RootPath: tempDir, MaxDepth: tt.maxDepth})
+
+			require.NoError(t, err)
+			assert.Len(t, result.Repositories, tt.want)
+		})
+	}
+}
+
+// Test Scan() applying exclude/include patterns, default excludes and the hidden switch.
+func TestScan_ExcludeInclude(t *testing.T) {
+	tempDir := t.TempDir()
+
+	createTestRepo(t, filepath.Join(tempDir, "work", "app"), false)
+	createTestRepo(t, filepath.Join(tempDir, "archive", "old"), false)
+	createTestRepo(t, filepath.Join(tempDir, "web", "node_modules", "pkg"), false)
+	createTestRepo(t, filepath.Join(tempDir, ".config", "dotfiles"), false)
+	createTestRepo(t, filepath.Join(tempDir, "mirrors", "tmp", "mirror"), false)
+
+	tests := []struct {
+		name        string
+		opts        ScanOptions
+		wantRepos   []string
+		wantSkipped int
+	}{
+		{
+			name:        "default excludes only",
+			opts:        ScanOptions{},
+			wantRepos:   []string{"app", "old", "dotfiles", "mirror"},
+			wantSkipped: 1,
+		},
+		{
+			name:        "no default excludes",
+			opts:        ScanOptions{NoDefaultExcludes: true},
+			wantRepos:   []string{"app", "old", "pkg", "dotfiles", "mirror"},
+			wantSkipped: 0,
+		},
+		{
+			name:        "exclude by name and by relative path",
+			opts:        ScanOptions{Exclude: []string{"archive", "mirrors/tmp"}},
+			wantRepos:   []string{"app", "dotfiles"},
+			wantSkipped: 3,
+		},
+		{
+			name:        "skip hidden",
+			opts:        ScanOptions{SkipHidden: true},
+			wantRepos:   []string{"app", "old", "mirror"},
+			wantSkipped: 2,
+		},
+		{
+			name:        "include overrides exclude and defaults",
+			opts:        ScanOptions{Exclude: []string{"arch*"}, Include: []string{"archive", "node_modules"}},
+			wantRepos:   []string{"app", "old", "pkg", "dotfiles", "mirror"},
+			wantSkipped: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.RootPath = tempDir
+			result, err := Scan(context.Background(), opts)
+
+			require.NoError(t, err)
+
+			names := make([]string, 0, len(result.Repositories))
+			for _, repo := range result.Repositories {
+				names = append(names, repo.Name)
+			}
+			assert.ElementsMatch(t, tt.wantRepos, names)
+			assert.Equal(t, tt.wantSkipped, result.TotalSkipped)
+		})
+	}
+}
+
+// Test Scan() skipping the home directory excludes only directly under the home directory.
+func TestScan_HomeExcludes(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+
+	createTestRepo(t, filepath.Join(homeDir, ".cache", "downloaded"), false)
+	createTestRepo(t, filepath.Join(homeDir, "work", ".cache", "cached"), false)
+	createTestRepo(t, filepath.Join(homeDir, "work", "Library", "lib"), false)
+	createTestRepo(t, filepath.Join(homeDir, "Library", "app"), false)
+
+	result, err := Scan(context.Background(), ScanOptions{RootPath: homeDir})
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		names = append(names, repo.Name)
+	}
+	wantRepos := []string{"cached", "lib"}
+	if runtime.GOOS != "darwin" {
+		wantRepos = append(wantRepos, "app")
+	}
+	assert.ElementsMatch(t, wantRepos, names)
+
+	assert.NotContains(t, defaultHomeExcludes("linux"), "Library")
+	assert.Contains(t, defaultHomeExcludes("darwin"), "Library")
+}
+
+// Test Scan() rejecting invalid options.
+func TestScan_InvalidExcludeOptions(t *testing.T) {
+	tempDir := t.TempDir()
+
+	_, err := Scan(context.Background(), ScanOptions{RootPath: tempDir, Exclude: []string{"[invalid"}})
+	require.ErrorIs(t, err, errScanResultValidation)
+
+	_, err = Scan(context.Background(), ScanOptions{RootPath: tempDir, MaxDepth: -1})
+	require.ErrorIs(t, err, errScanResultValidation)
+
+	_, err = Scan(context.Background(), ScanOptions{RootPath: tempDir, Concurrency: -1})
+	require.ErrorIs(t, err, errScanResultValidation)
+}
+
+// Test Scan() honoring .gitreeignore files hierarchically.
+func TestScan_TreeIgnoreFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	createTestRepo(t, filepath.Join(tempDir, "team", "service"), false)
+	createTestRepo(t, filepath.Join(tempDir, "team", "experiments", "spike"), false)
+	createTestRepo(t, filepath.Join(tempDir, "team", "experiments", "keep", "proto"), false)
+	createTestRepo(t, filepath.Join(tempDir, "scratch", "tmp-clone"), false)
+	createTestRepo(t, filepath.Join(tempDir, "other", "scratch", "clone"), false)
+
+	// Workspace-level rules: anchored pattern only applies at the top
+	err := os.WriteFile(filepath.Join(tempDir, TreeIgnoreFile), []byte("# workspace rules\n/scratch/\n"), 0o600)
+	require.NoError(t, err)
+	// Team-level rules: ignore experiments except "keep"
+	err = os.WriteFile(filepath.Join(tempDir, "team", TreeIgnoreFile), []byte("experiments/*\n!experiments/keep/\n"), 0o600)
+	require.NoError(t, err)
+
+	result, err := Scan(context.Background(), ScanOptions{RootPath: tempDir})
+
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		names = append(names, repo.Name)
+	}
+	assert.ElementsMatch(t, []string{"service", "proto", "clone"}, names)
+	assert.Equal(t, 2, result.TotalSkipped, "scratch and experiments/spike should be skipped")
+}
+
+// Test that rule descriptions point at the file and line that excluded a path.
+func TestIgnoreRule_String(t *testing.T) {
+	tempDir := t.TempDir()
+	ignoreFile := filepath.Join(tempDir, TreeIgnoreFile)
+	err := os.WriteFile(ignoreFile, []byte("# comment\n\nbuild/\n"), 0o600)
+	require.NoError(t, err)
+
+	rules, err := readIgnoreRules(ignoreFile, tempDir)
+
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, ignoreFile+":3: build/", rules[0].String())
+}
+
+// Test mountReason() refusing to cross into other and special file systems.
+func TestMountReason(t *testing.T) {
+	tempDir := t.TempDir()
+	info, err := os.Stat(tempDir)
+	require.NoError(t, err)
+	sameDev := rootDevice(info)
+
+	// Same device as the root: always walked
+	s := &scanner{opts: ScanOptions{OneFileSystem: true}, rootDev: sameDev, fsTypes: make(map[uint64]string)}
+	reason, err := s.mountReason(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+
+	// Different device than the root: skipped in one-file-system mode
+	s.rootDev = sameDev + 1
+	reason, err = s.mountReason(tempDir)
+	require.NoError(t, err)
+	assert.Equal(t, "different file system", reason)
+
+	// Neither option set: never skipped
+	s.opts = ScanOptions{}
+	reason, err = s.mountReason(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, reason)
+}
+
+// Test mountReason() detecting pseudo file systems via statfs.
+func TestMountReason_SpecialFileSystem(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Skipping /proc test on non-Linux systems")
+	}
+	if _, err := os.Stat("/proc/self"); err != nil {
+		t.Skip("/proc is not mounted")
+	}
+
+	s := &scanner{opts: ScanOptions{SkipSpecialFS: true}, fsTypes: make(map[uint64]string)}
+	info, err := os.Stat("/proc")
+	require.NoError(t, err)
+	s.rootDev = rootDevice(info) + 1
+
+	reason, err := s.mountReason("/proc")
+
+	require.NoError(t, err)
+	assert.Equal(t, "proc file system", reason)
+}
+
+// Test Scan() applying the FollowSymlinks policy.
+func TestScan_FollowSymlinksPolicy(t *testing.T) {
+	tempDir := t.TempDir()
+	rootDir := filepath.Join(tempDir, "root")
+	outsideDir := filepath.Join(tempDir, "outside")
+
+	createTestRepo(t, filepath.Join(rootDir, "real", "inner-repo"), false)
+	createTestRepo(t, filepath.Join(outsideDir, "external-repo"), false)
+
+	if err := os.Symlink(filepath.Join(rootDir, "real"), filepath.Join(rootDir, "a-link")); err != nil {
+		t.Skip("Symlink creation not supported on this system")
+	}
+	require.NoError(t, os.Symlink(outsideDir, filepath.Join(rootDir, "ext-link")))
+	// Loop back to the root: must not be walked twice
+	require.NoError(t, os.Symlink(rootDir, filepath.Join(rootDir, "real", "loop")))
+
+	tests := []struct {
+		policy    FollowSymlinks
+		wantPaths []string
+	}{
+		{
+			policy:    FollowSymlinksNever,
+			wantPaths: []string{"real/inner-repo"},
+		},
+		{
+			// The link sorts before its target, so the repo is found through it first
+			policy:    FollowSymlinksWithinRoot,
+			wantPaths: []string{"a-link/inner-repo"},
+		},
+		{
+			policy:    FollowSymlinksAlways,
+			wantPaths: []string{"a-link/inner-repo", "ext-link/external-repo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			result, err := Scan(context.Background(), ScanOptions{RootPath: rootDir, FollowSymlinks: tt.policy})
+
+			require.NoError(t, err)
+
+			paths := make([]string, 0, len(result.Repositories))
+			for _, repo := range result.Repositories {
+				relPath, err := filepath.Rel(rootDir, repo.Path)
+				require.NoError(t, err)
+				paths = append(paths, filepath.ToSlash(relPath))
+				assert.Equal(t, tt.policy != FollowSymlinksNever, repo.IsSymlink, "%s symlink flag", relPath)
+			}
+			assert.ElementsMatch(t, tt.wantPaths, paths)
+		})
+	}
+}
+
+// Test Scan() reading a directory reached through many symlink paths once.
+func TestScan_FollowSymlinksVisitsEachDirectoryOnce(t *testing.T) {
+	tempDir := t.TempDir()
+	rootDir := filepath.Join(tempDir, "root")
+	levelsDir := filepath.Join(tempDir, "levels")
+	require.NoError(t, os.MkdirAll(rootDir, 0o755))
+
+	// Each level links twice to the next: 2^levels paths lead to the last one
+	const levels = 16
+	level := func(i int) string {
+		return filepath.Join(levelsDir, fmt.Sprintf("l%02d", i))
+	}
+	createTestRepo(t, filepath.Join(level(levels), "repo"), false)
+	for i := levels - 1; i >= 1; i-- {
+		require.NoError(t, os.MkdirAll(level(i), 0o755))
+	}
+	if err := os.Symlink(level(1), filepath.Join(rootDir, "a")); err != nil {
+		t.Skip("Symlink creation not supported on this system")
+	}
+	require.NoError(t, os.Symlink(level(1), filepath.Join(rootDir, "b")))
+	for i := 1; i < levels; i++ {
+		require.NoError(t, os.Symlink(level(i+1), filepath.Join(level(i), "a")))
+		require.NoError(t, os.Symlink(level(i+1), filepath.Join(level(i), "b")))
+	}
+
+	result, err := Scan(context.Background(), ScanOptions{RootPath: rootDir, FollowSymlinks: FollowSymlinksAlways})
+
+	require.NoError(t, err)
+	require.Len(t, result.Repositories, 1)
+	assert.Equal(t, filepath.Join(rootDir, strings.Repeat("a"+string(filepath.Separator), levels)+"repo"),
+		result.Repositories[0].Path)
+	// The root, every level and the repository
+	assert.Equal(t, levels+2, result.TotalScanned)
+}
+
+// Test Scan() walking a directory under its own path when a symlink to it is skipped.
+func TestScan_SkippedSymlinkDoesNotHideTarget(t *testing.T) {
+	tests := []struct {
+		name       string
+		link       string
+		opts       ScanOptions
+		treeIgnore string
+	}{
+		{name: "exclude pattern", link: "link", opts: ScanOptions{Exclude: []string{"link"}}},
+		{name: "hidden switch", link: ".link", opts: ScanOptions{SkipHidden: true}},
+		{name: ".gitreeignore rule", link: "link", treeIgnore: "/link/\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The link sorts before its target, so it is looked at first
+			rootDir := t.TempDir()
+			createTestRepo(t, filepath.Join(rootDir, "real"), false)
+			if err := os.Symlink(filepath.Join(rootDir, "real"), filepath.Join(rootDir, tt.link)); err != nil {
+				t.Skip("Symlink creation not supported on this system")
+			}
+			if tt.treeIgnore != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(rootDir, TreeIgnoreFile), []byte(tt.treeIgnore), 0o600))
+			}
+
+			opts := tt.opts
+			opts.RootPath = rootDir
+			opts.FollowSymlinks = FollowSymlinksAlways
+			result, err := Scan(context.Background(), opts)
+
+			require.NoError(t, err)
+			require.Len(t, result.Repositories, 1)
+			assert.Equal(t, filepath.Join(rootDir, "real"), result.Repositories[0].Path)
+			assert.False(t, result.Repositories[0].IsSymlink)
+		})
+	}
+}
+
+// Test ParseFollowSymlinks() validating policy names.
+func TestParseFollowSymlinks(t *testing.T) {
+	for _, value := range []string{"never", "within-root", "always"} {
+		policy, err := ParseFollowSymlinks(value)
+		require.NoError(t, err)
+		assert.Equal(t, FollowSymlinks(value), policy)
+	}
+
+	_, err := ParseFollowSymlinks("sometimes")
+	require.ErrorIs(t, err, errInvalidFollowSymlinks)
+}
+
+// Test that directories are identified by (device, inode), not inode alone.
+func TestStatDir_KeysOnDeviceAndInode(t *testing.T) {
+	tempDir := t.TempDir()
+	info, err := os.Stat(tempDir)
+	require.NoError(t, err)
+	id, ok := fileIdentity(info)
+	if !ok {
+		t.Skip("Inode information not available on this system")
+	}
+
+	stat, err := statDir(tempDir)
+	require.NoError(t, err)
+	assert.False(t, stat.isSymlink)
+	assert.Equal(t, id, stat.id)
+	assert.NotZero(t, id.ino)
+	assert.Equal(t, info.ModTime(), stat.modTime)
+
+	// Same inode number on a different device is a different directory
+	assert.NotEqual(t, fileID{dev: id.dev + 1, ino: id.ino}, stat.id)
+
+	// A link resolves to the directory it points to
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(tempDir, link); err != nil {
+		t.Skip("Symlink creation not supported on this system")
+	}
+	linkStat, err := statDir(link)
+	require.NoError(t, err)
+	assert.True(t, linkStat.isSymlink)
+	assert.Equal(t, stat.id, linkStat.id)
+}
+
+// Test that the result does not depend on the number of workers.
+func TestScan_ConcurrencyDeterministic(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, rel := range []string{"b/repo2", "a/repo1", "a/z/repo3", "c", "a/repo1b", "d/e/f/repo4"} {
+		createTestRepo(t, filepath.Join(tempDir, rel), false)
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "empty", "dir"), 0o750))
+
+	sequential, err := Scan(context.Background(), ScanOptions{RootPath: tempDir, Concurrency: 1})
+	require.NoError(t, err)
+	require.Len(t, sequential.Repositories, 6)
+
+	wantPaths := make([]string, 0, len(sequential.Repositories))
+	for _, repo := range sequential.Repositories {
+		wantPaths = append(wantPaths, repo.Path)
+	}
+	assert.Equal(t, []string{
+		filepath.Join(tempDir, "a", "repo1"),
+		filepath.Join(tempDir, "a", "repo1b"),
+		filepath.Join(tempDir, "a", "z", "repo3"),
+		filepath.Join(tempDir, "b", "repo2"),
+		filepath.Join(tempDir, "c"),
+		filepath.Join(tempDir, "d", "e", "f", "repo4"),
+	}, wantPaths, "Repositories should be in walk order")
+
+	for range 5 {
+		parallel, err := Scan(context.Background(), ScanOptions{RootPath: tempDir, Concurrency: 16})
+		require.NoError(t, err)
+
+		paths := make([]string, 0, len(parallel.Repositories))
+		for _, repo := range parallel.Repositories {
+			paths = append(paths, repo.Path)
+		}
+		assert.Equal(t, wantPaths, paths)
+		assert.Equal(t, sequential.TotalScanned, parallel.TotalScanned)
+	}
+}
+
+// Test ScanStream() sending every repository before closing and reporting the final result.
+func TestScanStream(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, rel := range []string{"b/repo2", "a/repo1", "c"} {
+		createTestRepo(t, filepath.Join(tempDir, rel), false)
+	}
+
+	stream, err := ScanStream(context.Background(), ScanOptions{RootPath: tempDir})
+	require.NoError(t, err)
+
+	var streamed []string
+	for repo := range stream.Repositories {
+		streamed = append(streamed, repo.Path)
+	}
+
+	result, err := stream.Result()
+	require.NoError(t, err)
+
+	paths := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		paths = append(paths, repo.Path)
+	}
+	assert.ElementsMatch(t, paths, streamed, "Every repository should be streamed")
+	assert.Equal(t, []string{
+		filepath.Join(tempDir, "a", "repo1"),
+		filepath.Join(tempDir, "b", "repo2"),
+		filepath.Join(tempDir, "c"),
+	}, paths, "Result should be in walk order")
+
+	_, err = ScanStream(context.Background(), ScanOptions{RootPath: filepath.Join(tempDir, "missing")})
+	require.ErrorIs(t, err, errScanResultValidation, "Invalid options should be reported right away")
+}
+
+// ageDirectories sets the mtime of every directory below root to an hour ago,
+// so the scan cache trusts their listings.
+func ageDirectories(t *testing.T, root string) time.Time {
+	t.Helper()
+
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+
+		return os.Chtimes(path, old, old)
+	})
+	require.NoError(t, err)
+
+	return old
+}
+
+func scanPaths(t *testing.T, opts ScanOptions) []string {
+	t.Helper()
+
+	result, err := Scan(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Empty(t, result.Errors)
+
+	paths := make([]string, 0, len(result.Repositories))
+	for _, repo := range result.Repositories {
+		relPath, err := filepath.Rel(opts.RootPath, repo.Path)
+		require.NoError(t, err)
+		paths = append(paths, filepath.ToSlash(relPath))
+	}
+
+	return paths
+}
+
+// Test Scan() reusing cached listings of unchanged directories.
+func TestScan_Cache(t *testing.T) {
+	rootDir := t.TempDir()
+	cacheDir := t.TempDir()
+	createTestRepo(t, filepath.Join(rootDir, "a", "repo1"), false)
+	createTestRepo(t, filepath.Join(rootDir, "b", "repo2"), false)
+	old := ageDirectories(t, rootDir)
+
+	opts := ScanOptions{RootPath: rootDir, CacheDir: cacheDir}
+	assert.Equal(t, []string{"a/repo1", "b/repo2"}, scanPaths(t, opts))
+	assert.FileExists(t, cacheFilePath(cacheDir, rootDir))
+
+	// A repository created without changing the directory mtime is not noticed...
+	createTestRepo(t, filepath.Join(rootDir, "a", "new"), false)
+	require.NoError(t, os.Chtimes(filepath.Join(rootDir, "a"), old, old))
+	assert.Equal(t, []string{"a/repo1", "b/repo2"}, scanPaths(t, opts), "Unchanged directory should be listed from cache")
+
+	// ...until a rescan is requested
+	rescan := opts
+	rescan.Rescan = true
+	assert.Equal(t, []string{"a/new", "a/repo1", "b/repo2"}, scanPaths(t, rescan))
+
+	// Changed directories are walked again
+	createTestRepo(t, filepath.Join(rootDir, "b", "repo3"), false)
+	assert.Equal(t, []string{"a/new", "a/repo1", "b/repo2", "b/repo3"}, scanPaths(t, opts))
+
+	// A deleted repository still listed in the cache is dropped without errors
+	require.NoError(t, os.RemoveAll(filepath.Join(rootDir, "a", "repo1")))
+	require.NoError(t, os.Chtimes(filepath.Join(rootDir, "a"), old, old))
+	assert.Equal(t, []string{"a/new", "b/repo2", "b/repo3"}, scanPaths(t, opts))
+}
+
+// Test that cache files of another version or root are ignored.
+func TestLoadCache_Mismatch(t *testing.T) {
+	cacheDir := t.TempDir()
+	file := cacheFilePath(cacheDir, "/src")
+
+	dirs, err := loadCache(file, "/src")
+	require.NoError(t, err, "Missing cache file should not be an error")
+	assert.Nil(t, dirs)
+
+	require.NoError(t, saveCache(file, "/src", map[string]*cachedDir{"/src": {ModTime: 1, Listed: true}}))
+	dirs, err = loadCache(file, "/src")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]*cachedDir{"/src": {ModTime: 1, Listed: true}}, dirs)
+
+	_, err = loadCache(file, "/other")
+	require.ErrorIs(t, err, errCacheMismatch)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"version": 999, "root": "/src"}`), 0o600))
+	_, err = loadCache(file, "/src")
+	require.ErrorIs(t, err, errCacheMismatch)
+
+	require.NoError(t, os.WriteFile(file, []byte(`not json`), 0o600))
+	_, err = loadCache(file, "/src")
+	require.Error(t, err)
+}
+
+// createBenchmarkTree creates a directory tree shaped like a home directory:
+// width top-level project groups, each holding repositories and plain directories.
+func createBenchmarkTree(b *testing.B, width int) string {
+	b.Helper()
+
+	root := b.TempDir()
+	for i := range width {
+		group := filepath.Join(root, fmt.Sprintf("group%02d", i))
+		for j := range width {
+			repoPath := filepath.Join(group, fmt.Sprintf("repo%02d", j))
+			for _, dir := range []string{".git/refs", ".git/objects", "src/pkg"} {
+				if err := os.MkdirAll(filepath.Join(repoPath, dir), 0o750); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if err := os.WriteFile(filepath.Join(repoPath, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0o600); err != nil {
+				b.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Join(group, fmt.Sprintf("docs%02d", j), "a", "b", "c"), 0o750); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	return root
+}
+
+// Benchmark the sequential walk against the worker pool.
+func BenchmarkScan(b *testing.B) {
+	root := createBenchmarkTree(b, 20)
+
+	for _, concurrency := range []int{1, 4, DefaultConcurrency, 32} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for b.Loop() {
+				if _, err := Scan(context.Background(), ScanOptions{RootPath: root, Concurrency: concurrency}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
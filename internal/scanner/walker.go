@@ -0,0 +1,542 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreygrechin/gitree/internal/models"
+)
+
+// DefaultConcurrency is the number of directories read in parallel when
+// ScanOptions.Concurrency is not set. Walking is I/O bound, so it does not
+// need to follow the CPU count.
+const DefaultConcurrency = 8
+
+// dirTask is a directory waiting to be visited, together with the state
+// inherited from the directories enclosing it. Tasks are never modified
+// once queued, so workers can share the slices they hold.
+type dirTask struct {
+	path        string      // Directory path (below a followed symlink, reported under the link)
+	stat        *dirStat    // The directory on disk, looked up when it was claimed
+	viaSymlink  bool        // Whether the directory was reached through a followed symlink
+	ancestors   []fileID    // Enclosing directories, to report symlink loops
+	ignoredBy   *ignoreRule // .gitignore rule of the enclosing repository ignoring the directory (nested mode)
+	gitIgnores  ignoreStack // .gitignore rules of enclosing repositories (nested mode)
+	treeIgnores ignoreStack // .gitreeignore rules of enclosing directories
+}
+
+// workQueue is the LIFO queue of directories shared by the walker's workers.
+// LIFO order keeps the walk close to depth-first, which bounds the queue's size.
+type workQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   []*dirTask
+	pending int  // Tasks queued or being visited
+	closed  bool // Set when the walk is aborted
+}
+
+func newWorkQueue() *workQueue {
+	q := &workQueue{}
+	q.cond = sync.NewCond(&q.mu)
+
+	return q
+}
+
+// push queues tasks and wakes idle workers.
+func (q *workQueue) push(tasks ...*dirTask) {
+	if len(tasks) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.tasks = append(q.tasks, tasks...)
+	q.pending += len(tasks)
+	q.cond.Broadcast()
+}
+
+// pop blocks until a task is available. It returns nil once every task
+// has been visited or the queue was closed.
+func (q *workQueue) pop() *dirTask {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.tasks) == 0 && q.pending > 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if q.closed || len(q.tasks) == 0 {
+		return nil
+	}
+
+	task := q.tasks[len(q.tasks)-1]
+	q.tasks = q.tasks[:len(q.tasks)-1]
+
+	return task
+}
+
+// done marks a popped task as visited.
+func (q *workQueue) done() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending--
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+}
+
+// close aborts the walk; workers stop after their current task.
+func (q *workQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// walk visits the directory tree below the scan root with a bounded pool of
+// workers. It returns the first fatal error, or the context error if the
+// walk was canceled.
+func (s *scanner) walk(ctx context.Context) error {
+	workers := s.opts.Concurrency
+	if workers <= 0 {
+		workers = DefaultConcurrency
+	}
+
+	q := newWorkQueue()
+	if stat := s.claimDir(s.rootPath, nil); stat != nil {
+		q.push(&dirTask{path: s.rootPath, stat: stat})
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		fatalErr error
+	)
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for task := q.pop(); task != nil; task = q.pop() {
+				children, err := s.visitDir(ctx, task)
+				if err != nil {
+					errOnce.Do(func() { fatalErr = err })
+					q.close()
+				}
+				q.push(children...)
+				q.done()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return fatalErr
+}
+
+// visitDir checks a single directory and returns the subdirectories to visit next.
+func (s *scanner) visitDir(ctx context.Context, task *dirTask) ([]*dirTask, error) {
+	// Check for context cancellation
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	path := task.path
+	debugPrintf(s.opts.Debug, "Entering directory: %s", path)
+
+	s.mu.Lock()
+	s.dirCount++
+	s.mu.Unlock()
+
+	// Everything below a followed symlink is reached via that symlink
+	stat := task.stat
+	isSymlink := stat.isSymlink || task.viaSymlink
+
+	gitIgnores := task.gitIgnores
+	treeIgnores := task.treeIgnores
+
+	// Reuse what the last scan learned if the directory has not changed since
+	cached := s.cachedListing(path, stat.modTime)
+	fresh := &cachedDir{}
+	s.remember(path, stat.modTime, fresh)
+
+	// Check if this directory is a Git repository
+	var isRepo, isBare bool
+	if cached != nil {
+		isRepo, isBare = cached.Repo, cached.Bare
+	} else {
+		isRepo, isBare = IsGitRepository(path)
+	}
+	fresh.Repo, fresh.Bare = isRepo, isBare
+
+	if isRepo {
+		repo := &models.Repository{
+			Path:      path,
+			Name:      filepath.Base(path),
+			IsBare:    isBare,
+			IsSymlink: isSymlink,
+			Kind:      models.RepositoryKindMain,
+		}
+
+		repoType := "regular"
+		if isBare {
+			repoType = "bare"
+			repo.GitDir = path
+		} else if gitDirInfo, err := ResolveGitDir(path); err == nil {
+			repo.Kind = gitDirInfo.Kind
+			repo.GitDir = gitDirInfo.GitDir
+			if repo.IsLinked() {
+				repoType = string(repo.Kind)
+			}
+		}
+		debugPrintf(s.opts.Debug, "Found git repository: %s (%s)", path, repoType)
+
+		s.mu.Lock()
+		s.found = append(s.found, repo)
+		s.mu.Unlock()
+
+		if s.stream != nil {
+			select {
+			case s.stream <- repo:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		// Nested mode: keep looking for repositories inside the working tree,
+		// skipping whatever the repository itself ignores (build output, caches)
+		if !s.opts.Nested || isBare || (s.opts.MaxDepth > 0 && s.depth(path) >= s.opts.MaxDepth) {
+			// Skip traversing into repository contents (FR-018)
+			// We found a repo, so we don't need to look inside it for more repos
+			debugPrintf(s.opts.Debug, "Skipping %s: inside git repository", path)
+
+			return nil, nil
+		}
+
+		gitIgnores = gitIgnores.with(s.readIgnoreFrame(path, true,
+			filepath.Join(path, ".gitignore"), filepath.Join(repo.GitDir, "info", "exclude")))
+	} else {
+		if gitIgnores.insideRepository() && task.ignoredBy == nil {
+			gitIgnores = gitIgnores.with(s.readIgnoreFrame(path, false, filepath.Join(path, ".gitignore")))
+		}
+
+		// Stop descending once the maximum depth is reached
+		if s.opts.MaxDepth > 0 && s.depth(path) >= s.opts.MaxDepth {
+			debugPrintf(s.opts.Debug, "Not descending into %s: maximum depth %d reached", path, s.opts.MaxDepth)
+
+			return nil, nil
+		}
+	}
+
+	treeIgnores = treeIgnores.with(s.readIgnoreFrame(path, false, filepath.Join(path, TreeIgnoreFile)))
+
+	entries, err := s.listDir(path, cached, fresh)
+	if err != nil {
+		// Handle permission errors (non-fatal)
+		if os.IsPermission(err) {
+			debugPrintf(s.opts.Debug, "Skipping %s: permission denied", path)
+			s.addError(fmt.Errorf("permission denied: %s: %w", path, errPermissionDenied))
+
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	ancestors := append(task.ancestors[:len(task.ancestors):len(task.ancestors)], stat.id)
+	children := make([]*dirTask, 0, len(entries))
+
+	// Entries are claimed in lexical order, so of several links to the same
+	// directory, the first one by name is walked
+	for _, entry := range entries {
+		childPath := filepath.Join(path, entry.Name)
+
+		// Symbolic links to directories are walked into only as the policy allows
+		if entry.Symlink && !s.followable(childPath) {
+			continue
+		}
+		if s.skipped(childPath, entry.Name, treeIgnores) {
+			continue
+		}
+
+		// Nested mode: what the enclosing repository ignores (build output, vendored
+		// clones) is only searched for repositories directly inside
+		var ignoredBy *ignoreRule
+		if task.ignoredBy != nil {
+			if isRepo, _ := IsGitRepository(childPath); !isRepo {
+				debugPrintf(s.opts.Debug, "Skipping %s: inside %s, ignored by %s", childPath, path, task.ignoredBy)
+				s.countSkipped()
+
+				continue
+			}
+		} else if gitIgnores.insideRepository() {
+			if rule := gitIgnores.ignoredBy(childPath); rule != nil {
+				if isRepo, _ := IsGitRepository(childPath); !isRepo {
+					debugPrintf(s.opts.Debug, "Looking for repositories directly inside %s only: ignored by %s", childPath, rule)
+					ignoredBy = rule
+				}
+			}
+		}
+
+		childStat := s.claimDir(childPath, ancestors)
+		if childStat == nil {
+			continue
+		}
+
+		children = append(children, &dirTask{
+			path:        childPath,
+			stat:        childStat,
+			viaSymlink:  isSymlink || entry.Symlink,
+			ancestors:   ancestors,
+			ignoredBy:   ignoredBy,
+			gitIgnores:  gitIgnores,
+			treeIgnores: treeIgnores,
+		})
+	}
+
+	// Queue in reverse so the LIFO queue hands out entries in lexical order
+	slices.Reverse(children)
+
+	return children, nil
+}
+
+// skipped reports whether a subdirectory is left out of the walk by the exclude
+// patterns, default excludes, hidden switch, .gitreeignore rules or mount options.
+// It runs before the directory is claimed, so that a directory skipped under one
+// path is still walked under another.
+func (s *scanner) skipped(path, name string, treeIgnores ignoreStack) bool {
+	// Apply exclude/include patterns, default excludes and the hidden switch
+	if reason := s.excludeReason(path, name); reason != "" {
+		debugPrintf(s.opts.Debug, "Skipping %s: %s", path, reason)
+		s.countSkipped()
+
+		return true
+	}
+
+	// Honor .gitreeignore rules of enclosing directories
+	if rule := treeIgnores.ignoredBy(path); rule != nil {
+		debugPrintf(s.opts.Debug, "Skipping %s: ignored by %s", path, rule)
+		s.countSkipped()
+
+		return true
+	}
+
+	// Stay on the root file system and/or away from pseudo and network mounts
+	reason, err := s.mountReason(path)
+	if os.IsNotExist(err) {
+		return false // Reported when the directory is claimed
+	}
+	if err != nil {
+		debugPrintf(s.opts.Debug, "Skipping %s: %v", path, err)
+		s.addError(fmt.Errorf("error checking mount %s: %w", path, err))
+
+		return true
+	}
+	if reason != "" {
+		debugPrintf(s.opts.Debug, "Skipping %s: mount point (%s)", path, reason)
+		s.mu.Lock()
+		s.skipCount++
+		s.mountsSkipped = append(s.mountsSkipped, path)
+		s.mu.Unlock()
+
+		return true
+	}
+
+	return false
+}
+
+// claimDir looks up a directory and claims it for the walk, so that a directory
+// reached through several symbolic links is visited once. It returns nil if the
+// directory is gone, cannot be read, or was claimed already.
+func (s *scanner) claimDir(path string, ancestors []fileID) *dirStat {
+	stat, err := statDir(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Removed since its parent was listed (or cached)
+			debugPrintf(s.opts.Debug, "Skipping %s: no longer exists", path)
+
+			return nil
+		}
+		debugPrintf(s.opts.Debug, "Skipping %s: %v", path, err)
+		s.addError(fmt.Errorf("error checking path %s: %w", path, err))
+
+		return nil
+	}
+	if stat.id == (fileID{}) {
+		return stat // Identity unknown, nothing to compare
+	}
+
+	// A directory enclosing itself was reached through a link
+	if slices.Contains(ancestors, stat.id) {
+		debugPrintf(s.opts.Debug, "Skipping %s: already visited (symlink loop)", path)
+
+		return nil
+	}
+
+	s.mu.Lock()
+	_, claimed := s.visited[stat.id]
+	if !claimed {
+		s.visited[stat.id] = struct{}{}
+	}
+	s.mu.Unlock()
+	if claimed {
+		debugPrintf(s.opts.Debug, "Skipping %s: already visited via another path", path)
+
+		return nil
+	}
+
+	return stat
+}
+
+// listDir returns the subdirectories and symbolic links of a directory, from
+// the cache if it is still valid, and records them in fresh for the next scan.
+func (s *scanner) listDir(path string, cached, fresh *cachedDir) ([]cachedEntry, error) {
+	if cached != nil && cached.Listed {
+		s.mu.Lock()
+		s.cacheHits++
+		s.mu.Unlock()
+
+		fresh.Listed, fresh.Entries = true, cached.Entries
+
+		return cached.Entries, nil
+	}
+
+	dirEntries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []cachedEntry
+	for _, entry := range dirEntries {
+		switch {
+		case entry.IsDir():
+			// Never descend into Git metadata (only reachable in nested mode)
+			if entry.Name() == ".git" {
+				continue
+			}
+			entries = append(entries, cachedEntry{Name: entry.Name()})
+		case entry.Type()&fs.ModeSymlink != 0:
+			entries = append(entries, cachedEntry{Name: entry.Name(), Symlink: true})
+		default:
+			// Only process directories
+		}
+	}
+	fresh.Listed, fresh.Entries = true, entries
+
+	return entries, nil
+}
+
+// dirStat is what the walker needs to know about a directory on disk.
+type dirStat struct {
+	id        fileID    // (device, inode) pair, or the resolved path where inode information is unavailable
+	modTime   time.Time // Modification time (of the target, for a symbolic link)
+	isSymlink bool      // Whether the path itself is a symbolic link
+}
+
+// statDir looks up a directory, resolving symbolic links.
+func statDir(path string) (*dirStat, error) {
+	// Get file info without following symlinks
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	stat := &dirStat{isSymlink: info.Mode()&os.ModeSymlink != 0}
+	if stat.isSymlink {
+		// Get info of the target
+		info, err = os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	stat.modTime = info.ModTime()
+
+	// Inode numbers are only unique within a file system. Without them, the
+	// directory is identified by its path with symbolic links resolved
+	if id, ok := fileIdentity(info); ok {
+		stat.id = id
+	} else if realPath, err := filepath.EvalSymlinks(path); err == nil {
+		stat.id = fileID{path: realPath}
+	}
+
+	return stat, nil
+}
+
+// followable reports whether the FollowSymlinks policy allows walking into the
+// directory a symbolic link points to. Paths below the link are reported under
+// the link's own path so repositories stay placed where the user sees them.
+func (s *scanner) followable(path string) bool {
+	if s.opts.FollowSymlinks == "" || s.opts.FollowSymlinks == FollowSymlinksNever {
+		return false
+	}
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		debugPrintf(s.opts.Debug, "Skipping %s: broken symlink: %v", path, err)
+
+		return false
+	}
+	info, err := os.Stat(target)
+	if err != nil || !info.IsDir() {
+		return false // Only symlinks to directories can lead to repositories
+	}
+
+	if s.opts.FollowSymlinks == FollowSymlinksWithinRoot && !isWithin(target, s.realRootPath) {
+		debugPrintf(s.opts.Debug, "Skipping %s: symlink target %s is outside the scan root", path, target)
+		s.countSkipped()
+
+		return false
+	}
+
+	debugPrintf(s.opts.Debug, "Following symlink %s -> %s", path, target)
+
+	return true
+}
+
+// countSkipped records a directory skipped by exclusion rules.
+func (s *scanner) countSkipped() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skipCount++
+}
+
+// addError records a non-fatal error.
+func (s *scanner) addError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errors = append(s.errors, err)
+}
+
+// compareWalkOrder orders paths as a sequential depth-first walk visiting
+// entries in lexical order would: parents before children, siblings by name.
+func compareWalkOrder(a, b string) int {
+	return slices.Compare(strings.Split(a, string(filepath.Separator)), strings.Split(b, string(filepath.Separator)))
+}
+
+// finish puts the walk's results into a deterministic order. Workers finish in
+// arbitrary order, so repositories and skipped mounts are sorted into walk order.
+func (s *scanner) finish() {
+	slices.SortFunc(s.found, func(a, b *models.Repository) int {
+		return compareWalkOrder(a.Path, b.Path)
+	})
+	s.repositories = s.found
+
+	slices.SortFunc(s.mountsSkipped, compareWalkOrder)
+	slices.SortFunc(s.errors, func(a, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+}
@@ -1,8 +1,14 @@
 package tree
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/andreygrechin/gitree/internal/models"
 )
@@ -14,6 +20,45 @@ type FormatOptions struct {
 
 	// RootLabel is the label to use for the root (e.g., ".")
 	RootLabel string
+
+	// Status configures how each repository's Git status is shown
+	Status models.StatusFormat
+
+	// ShowLastCommit adds a column with the last commit of each repository
+	ShowLastCommit bool
+
+	// SortBy orders the repositories of each directory (empty = SortByName)
+	SortBy SortOrder
+
+	// Now is the time relative dates are computed from (zero = time.Now())
+	Now time.Time
+
+	// ShowSubmodules adds the submodules of each repository as its children, with
+	// their state (see models.GitStatus.Submodules)
+	ShowSubmodules bool
+}
+
+// SortOrder is the order Build puts sibling nodes in.
+type SortOrder string
+
+const (
+	// SortByName orders siblings alphabetically (default).
+	SortByName SortOrder = "name"
+	// SortByActivity orders siblings by their last commit, most recent first. A
+	// directory counts as active as the most recently active repository below it.
+	SortByActivity SortOrder = "activity"
+)
+
+var errInvalidSortOrder = errors.New("invalid sort order")
+
+// ParseSortOrder converts an order name into a SortOrder value.
+func ParseSortOrder(value string) (SortOrder, error) {
+	switch order := SortOrder(value); order {
+	case SortByName, SortByActivity:
+		return order, nil
+	default:
+		return "", fmt.Errorf("%w: %q (must be %s or %s)", errInvalidSortOrder, value, SortByName, SortByActivity)
+	}
 }
 
 // DefaultFormatOptions returns sensible defaults.
@@ -47,6 +92,7 @@ func Build(rootPath string, repos []*models.Repository, opts *FormatOptions) *mo
 	}
 
 	// Build tree by organizing repos into hierarchy
+	nodes := make(map[string]*models.TreeNode, len(repos))
 	for _, repo := range repos {
 		relPath, err := filepath.Rel(rootPath, repo.Path)
 		if err != nil {
@@ -55,17 +101,24 @@ func Build(rootPath string, repos []*models.Repository, opts *FormatOptions) *mo
 		}
 
 		// Create or find nodes for this repository
-		insertIntoTree(root, repo, relPath, rootPath)
+		nodes[repo.Path] = insertIntoTree(root, repo, relPath, rootPath)
+	}
+
+	if opts.ShowSubmodules {
+		insertSubmodules(root, repos, nodes, rootPath)
 	}
 
 	// Sort all children alphabetically and mark IsLast flags
 	sortTree(root)
+	if opts.SortBy == SortByActivity {
+		sortByActivity(root)
+	}
 
 	return root
 }
 
-// insertIntoTree inserts a repository into the tree at the correct location.
-func insertIntoTree(root *models.TreeNode, repo *models.Repository, relPath, rootPath string) {
+// insertIntoTree inserts a repository into the tree at the correct location and returns its node.
+func insertIntoTree(root *models.TreeNode, repo *models.Repository, relPath, rootPath string) *models.TreeNode {
 	parts := strings.Split(filepath.ToSlash(relPath), "/")
 
 	current := root
@@ -102,6 +155,17 @@ func insertIntoTree(root *models.TreeNode, repo *models.Repository, relPath, roo
 		}
 	}
 
+	// A nested repository inserted earlier may have created a placeholder
+	// directory node for this repository; promote it instead of duplicating it
+	for _, child := range current.Children {
+		if child.Repository.Path == repo.Path {
+			child.Repository = repo
+			child.RelativePath = relPath
+
+			return child
+		}
+	}
+
 	// Add the actual repository node
 	repoNode := &models.TreeNode{
 		Repository:   repo,
@@ -109,6 +173,39 @@ func insertIntoTree(root *models.TreeNode, repo *models.Repository, relPath, roo
 		RelativePath: relPath,
 	}
 	current.Children = append(current.Children, repoNode)
+
+	return repoNode
+}
+
+// insertSubmodules attaches the state of each repository's submodules to their nodes,
+// adding nodes for the submodules that were not found while scanning (e.g. uninitialized
+// ones, or all of them without nested scanning).
+func insertSubmodules(root *models.TreeNode, repos []*models.Repository, nodes map[string]*models.TreeNode, rootPath string) {
+	for _, repo := range repos {
+		if repo.GitStatus == nil || nodes[repo.Path] == nil {
+			continue
+		}
+
+		for i := range repo.GitStatus.Submodules {
+			sub := &repo.GitStatus.Submodules[i]
+			subPath := filepath.Join(repo.Path, filepath.FromSlash(sub.Path))
+
+			node, found := nodes[subPath]
+			if !found {
+				relPath, err := filepath.Rel(rootPath, subPath)
+				if err != nil {
+					continue
+				}
+				node = insertIntoTree(root, &models.Repository{
+					Path: subPath,
+					Name: filepath.Base(subPath),
+					Kind: models.RepositoryKindSubmodule,
+				}, relPath, rootPath)
+				nodes[subPath] = node
+			}
+			node.Submodule = sub
+		}
+	}
 }
 
 // sortTree recursively sorts all children alphabetically and sets depth/IsLast flags.
@@ -127,6 +224,40 @@ func sortTree(node *models.TreeNode) {
 	}
 }
 
+// sortByActivity reorders the (alphabetically sorted) children of every node by
+// last activity, most recent first, and returns the node's own last activity.
+func sortByActivity(node *models.TreeNode) time.Time {
+	activity := make(map[*models.TreeNode]time.Time, len(node.Children))
+	for _, child := range node.Children {
+		activity[child] = sortByActivity(child)
+	}
+
+	slices.SortStableFunc(node.Children, func(a, b *models.TreeNode) int {
+		return activity[b].Compare(activity[a])
+	})
+	for i, child := range node.Children {
+		child.IsLast = i == len(node.Children)-1
+	}
+
+	var latest time.Time
+	if status := node.Repository.GitStatus; status != nil && status.LastCommit != nil {
+		latest = status.LastCommit.Date
+	}
+	for _, t := range activity {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest
+}
+
+// treeLine is a line of tree output, with the optional last commit column.
+type treeLine struct {
+	text       string
+	lastCommit *models.CommitInfo
+}
+
 // Format generates ASCII tree output from a tree structure.
 func Format(root *models.TreeNode, opts *FormatOptions) string {
 	if opts == nil {
@@ -154,20 +285,62 @@ func Format(root *models.TreeNode, opts *FormatOptions) string {
 	}
 
 	// Format children
+	var lines []treeLine
 	for i, child := range root.Children {
 		isLast := (i == len(root.Children)-1)
-		formatNode(&builder, child, "", isLast)
+		lines = formatNode(lines, child, "", isLast, opts)
 	}
 
+	writeLines(&builder, lines, opts)
+
 	return builder.String()
 }
 
-// formatNode recursively formats a tree node with appropriate connectors.
-func formatNode(builder *strings.Builder, node *models.TreeNode, prefix string, isLast bool) {
+// writeLines writes formatted lines, aligning the last commit column if it is shown.
+func writeLines(builder *strings.Builder, lines []treeLine, opts *FormatOptions) {
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	width := 0
+	if opts.ShowLastCommit {
+		for _, line := range lines {
+			if line.lastCommit != nil {
+				width = max(width, visibleWidth(line.text))
+			}
+		}
+	}
+
+	for _, line := range lines {
+		builder.WriteString(line.text)
+		if opts.ShowLastCommit && line.lastCommit != nil {
+			builder.WriteString(strings.Repeat(" ", width-visibleWidth(line.text)+columnGap))
+			builder.WriteString(line.lastCommit.Format(now))
+		}
+		builder.WriteString("\n")
+	}
+}
+
+// columnGap is the minimum number of spaces before the last commit column.
+const columnGap = 2
+
+// ansiEscape matches the color escape sequences of colored status output.
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`) //nolint:gochecknoglobals // Compiled once, read-only
+
+// visibleWidth returns the number of characters of s shown on a terminal.
+func visibleWidth(s string) int {
+	return utf8.RuneCountInString(ansiEscape.ReplaceAllString(s, ""))
+}
+
+// formatNode recursively formats a tree node with appropriate connectors and appends its lines.
+func formatNode(lines []treeLine, node *models.TreeNode, prefix string, isLast bool, opts *FormatOptions) []treeLine {
 	if node == nil || node.Repository == nil {
-		return
+		return lines
 	}
 
+	var builder strings.Builder
+
 	// Choose connector based on whether this is the last child
 	connector := "├── "
 	if isLast {
@@ -182,29 +355,55 @@ func formatNode(builder *strings.Builder, node *models.TreeNode, prefix string,
 	// Add Git status if available
 	if node.Repository.GitStatus != nil {
 		builder.WriteString(" ")
-		builder.WriteString(node.Repository.GitStatus.Format())
+		builder.WriteString(node.Repository.GitStatus.FormatWith(opts.Status))
+	}
+
+	// Add submodule state recorded by the superproject; a scanned submodule shows its own
+	// status, which cannot tell whether the superproject expects another commit
+	if sub := node.Submodule; sub != nil {
+		state := sub.Format()
+		if node.Repository.GitStatus != nil {
+			state = sub.FormatRecorded()
+		}
+		if state != "" {
+			builder.WriteString(" " + state)
+		}
 	}
 
-	// Add error indicator if present
-	if node.Repository.Error != nil && node.Repository.GitStatus == nil {
+	// Add error indicator if present (the status shows it otherwise)
+	if node.Repository.Error != nil && node.Repository.GitStatus == nil && !node.Repository.HasTimeout {
 		builder.WriteString(" error")
 	}
 
-	// Add timeout indicator if present
-	if node.Repository.HasTimeout && node.Repository.GitStatus != nil && node.Repository.GitStatus.Error != "" {
+	// Add timeout indicator if present, also for repositories skipped before extraction started
+	if node.Repository.HasTimeout {
 		builder.WriteString(" timeout")
 	}
 
 	// Add bare indicator if this is a bare repository
 	if node.Repository.IsBare && node.Repository.GitStatus != nil {
 		// Check if "bare" is already in the status format
-		statusStr := node.Repository.GitStatus.Format()
+		statusStr := node.Repository.GitStatus.FormatWith(opts.Status)
 		if !strings.Contains(statusStr, "bare") {
 			builder.WriteString(" bare")
 		}
 	}
 
-	builder.WriteString("\n")
+	// Add linked working copy indicator (worktree or submodule)
+	if node.Repository.IsLinked() {
+		builder.WriteString(" " + string(node.Repository.Kind))
+	}
+
+	// Add symlink indicator if the repository was reached via a symbolic link
+	if node.Repository.IsSymlink {
+		builder.WriteString(" symlink")
+	}
+
+	line := treeLine{text: builder.String()}
+	if node.Repository.GitStatus != nil {
+		line.lastCommit = node.Repository.GitStatus.LastCommit
+	}
+	lines = append(lines, line)
 
 	// Format children with updated prefix
 	childPrefix := prefix
@@ -214,8 +413,26 @@ func formatNode(builder *strings.Builder, node *models.TreeNode, prefix string,
 		childPrefix += "│   " // Vertical bar and three spaces for non-last
 	}
 
+	// Audited branches come first, as leaves, then nested repositories
+	var branches []models.BranchStatus
+	if node.Repository.GitStatus != nil {
+		branches = node.Repository.GitStatus.Branches
+	}
+	for i := range branches {
+		connector := "├── "
+		if i == len(branches)-1 && len(node.Children) == 0 {
+			connector = "└── "
+		}
+		lines = append(lines, treeLine{text: childPrefix + connector + branchMarker + branches[i].Format()})
+	}
+
 	for i, child := range node.Children {
 		childIsLast := (i == len(node.Children)-1)
-		formatNode(builder, child, childPrefix, childIsLast)
+		lines = formatNode(lines, child, childPrefix, childIsLast, opts)
 	}
+
+	return lines
 }
+
+// branchMarker tells branch lines apart from nested repositories.
+const branchMarker = "⎇ "
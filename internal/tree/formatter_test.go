@@ -1,11 +1,15 @@
 package tree
 
 import (
+	"context"
 	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/andreygrechin/gitree/internal/models"
+	"github.com/fatih/color"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -245,6 +249,29 @@ func TestFormat_IncludesGitStatusInline(t *testing.T) {
 	assert.Contains(t, output, "*")
 }
 
+func TestFormat_DetailedChanges(t *testing.T) {
+	repos := []*models.Repository{
+		{
+			Path: "/root/project",
+			Name: "project",
+			GitStatus: &models.GitStatus{
+				Branch:     "main",
+				HasRemote:  true,
+				HasChanges: true,
+				Changes:    models.ChangeCounts{Staged: 3, Modified: 2, Untracked: 5, Unmerged: 1},
+			},
+		},
+	}
+
+	opts := &FormatOptions{ShowRoot: true, RootLabel: ".", Status: models.StatusFormat{Changes: models.ChangesDetailed}}
+	output := Format(Build("/root", repos, opts), opts)
+
+	for _, part := range []string{"+3", "~2", "?5", "!1"} {
+		assert.Contains(t, output, part)
+	}
+	assert.NotContains(t, output, "*")
+}
+
 // T064: Test Format() matching examples from cli-contract.md.
 func TestFormat_MatchesCliContractExamples(t *testing.T) {
 	// Example: Simple tree with status
@@ -338,6 +365,24 @@ func TestFormat_WithTimeoutIndicator(t *testing.T) {
 	assert.Contains(t, output, "timeout")
 }
 
+// A repository skipped at the deadline has no status: it shows as timed out, not as failed.
+func TestFormat_TimeoutWithoutStatus(t *testing.T) {
+	repos := []*models.Repository{
+		{
+			Path:       "/root/skipped",
+			Name:       "skipped",
+			Error:      context.DeadlineExceeded,
+			HasTimeout: true,
+		},
+	}
+
+	root := Build("/root", repos, nil)
+	output := Format(root, nil)
+
+	assert.Contains(t, output, "skipped timeout")
+	assert.NotContains(t, output, "error")
+}
+
 // Additional test: Format with bare repository.
 func TestFormat_WithBareRepository(t *testing.T) {
 	repos := []*models.Repository{
@@ -391,3 +436,297 @@ func TestFormat_VerticalPipeForNonLastChildren(t *testing.T) {
 	// When there's dir as non-last child with nested projects
 	assert.Contains(t, output, "│")
 }
+
+// Test Format with linked worktree and submodule indicators.
+func TestFormat_WithLinkedRepositories(t *testing.T) {
+	repos := []*models.Repository{
+		{
+			Path:      "/root/project",
+			Name:      "project",
+			Kind:      models.RepositoryKindMain,
+			GitStatus: &models.GitStatus{Branch: "main", HasRemote: true},
+		},
+		{
+			Path:      "/root/project-feature",
+			Name:      "project-feature",
+			Kind:      models.RepositoryKindWorktree,
+			GitStatus: &models.GitStatus{Branch: "feature", HasRemote: true},
+		},
+		{
+			Path:      "/root/vendor/dep",
+			Name:      "dep",
+			Kind:      models.RepositoryKindSubmodule,
+			GitStatus: &models.GitStatus{Branch: "DETACHED", IsDetached: true, HasRemote: true},
+		},
+	}
+
+	root := Build("/root", repos, nil)
+	output := Format(root, nil)
+
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for _, line := range lines {
+		switch {
+		case strings.Contains(line, "project-feature"):
+			assert.True(t, strings.HasSuffix(line, " worktree"), "worktree should be marked: %q", line)
+		case strings.Contains(line, "dep"):
+			assert.True(t, strings.HasSuffix(line, " submodule"), "submodule should be marked: %q", line)
+		case strings.Contains(line, "project"):
+			assert.NotContains(t, line, "worktree")
+		}
+	}
+}
+
+// Test Build() rendering nested repositories as children of their parent repository.
+func TestBuild_NestedRepositoryUnderParentRepository(t *testing.T) {
+	parent := &models.Repository{Path: "/root/parent", Name: "parent"}
+	nested := &models.Repository{Path: "/root/parent/libs/nested", Name: "nested"}
+
+	// Insertion order must not matter: the nested repo may come first
+	for _, repos := range [][]*models.Repository{{parent, nested}, {nested, parent}} {
+		root := Build("/root", repos, nil)
+
+		require.Len(t, root.Children, 1)
+		parentNode := root.Children[0]
+		assert.Same(t, parent, parentNode.Repository, "parent node should be the repository itself")
+		require.Len(t, parentNode.Children, 1)
+		libsNode := parentNode.Children[0]
+		assert.Equal(t, "libs", libsNode.Repository.Name)
+		require.Len(t, libsNode.Children, 1)
+		assert.Same(t, nested, libsNode.Children[0].Repository)
+	}
+}
+
+// Test Format with symlink indicator.
+func TestFormat_WithSymlinkIndicator(t *testing.T) {
+	repos := []*models.Repository{
+		{
+			Path:      "/root/linked",
+			Name:      "linked",
+			IsSymlink: true,
+			GitStatus: &models.GitStatus{Branch: "main", HasRemote: true},
+		},
+	}
+
+	root := Build("/root", repos, nil)
+	output := Format(root, nil)
+
+	assert.Contains(t, output, "linked [[ main ]] symlink")
+}
+
+func TestFormat_ShowLastCommitAlignsColumn(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	repos := []*models.Repository{
+		{
+			Path: "/root/a",
+			Name: "a",
+			GitStatus: &models.GitStatus{
+				Branch:     "main",
+				LastCommit: &models.CommitInfo{Hash: "1111111", Author: "Ann", Date: now.Add(-2 * time.Hour), Subject: "First"},
+			},
+		},
+		{
+			Path: "/root/nested/longer-name",
+			Name: "longer-name",
+			GitStatus: &models.GitStatus{
+				Branch:     "feature",
+				LastCommit: &models.CommitInfo{Hash: "2222222", Author: "Bob", Date: now.Add(-21 * 24 * time.Hour), Subject: "Second"},
+			},
+		},
+	}
+
+	opts := &FormatOptions{ShowRoot: true, RootLabel: ".", ShowLastCommit: true, Now: now}
+	lines := strings.Split(strings.TrimSuffix(Format(Build("/root", repos, opts), opts), "\n"), "\n")
+
+	require.Len(t, lines, 4)
+	assert.Equal(t, "├── a [[ main | ○ ]]                   1111111 · 2 hours ago · Ann · First", lines[1])
+	assert.Equal(t, "└── nested", lines[2], "directories have no last commit")
+	assert.Equal(t, "    └── longer-name [[ feature | ○ ]]  2222222 · 3 weeks ago · Bob · Second", lines[3])
+}
+
+func TestFormat_HidesLastCommitByDefault(t *testing.T) {
+	repos := []*models.Repository{
+		{
+			Path: "/root/a",
+			Name: "a",
+			GitStatus: &models.GitStatus{
+				Branch:     "main",
+				LastCommit: &models.CommitInfo{Hash: "1111111", Author: "Ann", Date: time.Now(), Subject: "First"},
+			},
+		},
+	}
+
+	output := Format(Build("/root", repos, nil), nil)
+	assert.NotContains(t, output, "1111111")
+}
+
+func TestBuild_SortsByActivity(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	committed := func(name string, age time.Duration) *models.Repository {
+		return &models.Repository{
+			Path: "/root/" + name,
+			Name: filepath.Base(name),
+			GitStatus: &models.GitStatus{
+				Branch:     "main",
+				LastCommit: &models.CommitInfo{Hash: "1234567", Date: now.Add(-age)},
+			},
+		}
+	}
+
+	repos := []*models.Repository{
+		committed("old", 30*24*time.Hour),
+		committed("recent", time.Hour),
+		committed("group/busy", time.Minute),
+		committed("group/quiet", 60*24*time.Hour),
+		{Path: "/root/unknown", Name: "unknown"},
+		committed("also-old", 30*24*time.Hour),
+	}
+
+	root := Build("/root", repos, &FormatOptions{RootLabel: ".", SortBy: SortByActivity})
+
+	var names []string
+	for _, child := range root.Children {
+		names = append(names, child.Repository.Name)
+	}
+	assert.Equal(t, []string{"group", "recent", "also-old", "old", "unknown"}, names,
+		"most recent first, directories by their most recent repository, ties by name")
+	assert.Equal(t, "busy", root.Children[0].Children[0].Repository.Name)
+	assert.True(t, root.Children[4].IsLast)
+	assert.False(t, root.Children[0].IsLast)
+}
+
+func TestParseSortOrder(t *testing.T) {
+	for _, value := range []string{"name", "activity"} {
+		order, err := ParseSortOrder(value)
+		require.NoError(t, err)
+		assert.Equal(t, SortOrder(value), order)
+	}
+
+	_, err := ParseSortOrder("size")
+	require.ErrorIs(t, err, errInvalidSortOrder)
+}
+
+func TestFormat_RendersBranchesAsChildren(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	repos := []*models.Repository{
+		{
+			Path: "/root/project",
+			Name: "project",
+			GitStatus: &models.GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				Branches: []models.BranchStatus{
+					{Name: "feature", Upstream: "origin/feature", Ahead: 2},
+					{Name: "wip", NoUpstream: true},
+				},
+			},
+		},
+		{
+			Path:      "/root/project/vendor/lib",
+			Name:      "lib",
+			GitStatus: &models.GitStatus{Branch: "main", HasRemote: true},
+		},
+		{
+			Path: "/root/tool",
+			Name: "tool",
+			GitStatus: &models.GitStatus{
+				Branch:    "main",
+				HasRemote: true,
+				Branches:  []models.BranchStatus{{Name: "old", UpstreamGone: true, Merged: true}},
+			},
+		},
+	}
+
+	output := Format(Build("/root", repos, nil), nil)
+
+	expected := `.
+├── project [[ main ]]
+│   ├── ⎇ feature [[ ↑2 ]]
+│   ├── ⎇ wip [[ ◇ ]]
+│   └── vendor
+│       └── lib [[ main ]]
+└── tool [[ main ]]
+    └── ⎇ old [[ ⊘ | merged ]]
+`
+	assert.Equal(t, expected, output)
+}
+
+func TestBuild_ShowsSubmodules(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	app := &models.Repository{
+		Path: "/root/app",
+		Name: "app",
+		GitStatus: &models.GitStatus{
+			Branch:    "main",
+			HasRemote: true,
+			Submodules: []models.SubmoduleStatus{
+				{Path: "libs/core", Recorded: "abc1234", Current: "def5678", OutOfSync: true},
+				{Path: "libs/ui", Recorded: "abc1234", Current: "abc1234"},
+				{Path: "vendor", Recorded: "abc1234", Uninitialized: true},
+			},
+		},
+	}
+	// Found by a nested scan
+	core := &models.Repository{
+		Path:      "/root/app/libs/core",
+		Name:      "core",
+		Kind:      models.RepositoryKindSubmodule,
+		GitStatus: &models.GitStatus{Branch: "DETACHED", IsDetached: true, HasRemote: true},
+	}
+	repos := []*models.Repository{app, core}
+
+	opts := &FormatOptions{ShowRoot: true, RootLabel: ".", ShowSubmodules: true}
+	output := Format(Build("/root", repos, opts), opts)
+
+	assert.Equal(t, `.
+└── app [[ main | sub:2 ]]
+    ├── libs
+    │   ├── core [[ DETACHED ]] out of sync submodule
+    │   └── ui submodule
+    └── vendor [[ uninitialized ]] submodule
+`, output)
+
+	// Without the option, only scanned repositories are shown
+	output = Format(Build("/root", repos, nil), nil)
+	assert.NotContains(t, output, "vendor")
+	assert.NotContains(t, output, "out of sync")
+}
+
+func TestBuild_ColorsSubmoduleStates(t *testing.T) {
+	color.NoColor = false // Enable colors
+
+	app := &models.Repository{
+		Path: "/root/app",
+		Name: "app",
+		GitStatus: &models.GitStatus{
+			Branch:    "main",
+			HasRemote: true,
+			Submodules: []models.SubmoduleStatus{
+				{Path: "scanned", Recorded: "abc1234", Current: "def5678", OutOfSync: true},
+				{Path: "unscanned", Recorded: "abc1234", Current: "def5678", OutOfSync: true},
+			},
+		},
+	}
+	scanned := &models.Repository{
+		Path:      "/root/app/scanned",
+		Name:      "scanned",
+		Kind:      models.RepositoryKindSubmodule,
+		GitStatus: &models.GitStatus{Branch: "DETACHED", IsDetached: true, HasRemote: true},
+	}
+
+	opts := &FormatOptions{ShowRoot: true, RootLabel: ".", ShowSubmodules: true}
+	lines := strings.Split(Format(Build("/root", []*models.Repository{app, scanned}, opts), opts), "\n")
+
+	outOfSync := color.New(color.FgRed, color.Bold).Sprint("out of sync")
+	require.Len(t, lines, 5)
+	assert.Contains(t, lines[2], "scanned")
+	assert.Contains(t, lines[2], " "+outOfSync+" ", "after the submodule's own status")
+	assert.Contains(t, lines[3], "unscanned")
+	assert.Contains(t, lines[3], " "+outOfSync+" ", "in the state recorded by the superproject")
+}